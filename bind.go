@@ -0,0 +1,206 @@
+package urit
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind binds the path vars into the fields of the destination struct (dst must be a non-nil pointer to a struct)
+//
+// Fields are bound from the path var named in the field's `urit` tag - e.g.
+//
+//	type params struct {
+//	    Year  int       `urit:"year"`
+//	    Month int       `urit:"month"`
+//	    Since time.Time `urit:"since,layout=2006-01-02"`
+//	}
+//
+// For positional path vars, the tag must be the position (e.g. `urit:"0"`)
+//
+// Supported field types are string, int (all sizes), uint (all sizes), float (32 & 64), bool, time.Duration,
+// time.Time (parsed as RFC3339 - unless a layout is specified in the tag), any type implementing encoding.TextUnmarshaler
+// and pointers to any of these - slice fields are bound from all the values for a repeated path var name
+//
+// Fields without a `urit` tag (or with a tag of "-") are not bound, and fields for path vars that are not present are left unchanged
+//
+// If a path var value cannot be converted to the field type, a VarConversionError is returned
+func Bind(vars PathVars, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a non-nil pointer to a struct")
+	}
+	if vars == nil {
+		return nil
+	}
+	return bindStruct(vars, rv.Elem())
+}
+
+func bindStruct(vars PathVars, sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		tag, hasTag := f.Tag.Lookup("urit")
+		if !hasTag {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if err := bindStruct(vars, sv.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		} else if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		name, layout := parseBindTag(tag, f.Name)
+		values := bindValues(vars, name)
+		if len(values) == 0 {
+			continue
+		}
+		fv := sv.Field(i)
+		if fv.Kind() == reflect.Slice && !isBindScalar(fv.Type()) {
+			if segs, ok := vars.GetSegments(bindIdent(vars, name)...); ok && len(values) == 1 {
+				// a catch-all var binds its segments into a slice...
+				values = segs
+			}
+			items := reflect.MakeSlice(fv.Type(), len(values), len(values))
+			for vi, v := range values {
+				if err := convertVar(v, items.Index(vi), layout); err != nil {
+					return newVarConversionError(name, v, f.Name, err)
+				}
+			}
+			fv.Set(items)
+		} else if err := convertVar(values[0], fv, layout); err != nil {
+			return newVarConversionError(name, values[0], f.Name, err)
+		}
+	}
+	return nil
+}
+
+func parseBindTag(tag string, fieldName string) (name string, layout string) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = fieldName
+	}
+	for _, opt := range parts[1:] {
+		if k, v, ok := strings.Cut(opt, "="); ok && k == "layout" {
+			layout = v
+		}
+	}
+	return
+}
+
+// bindValues returns the string values of the path var(s) for the name (or position, for positional path vars)
+func bindValues(vars PathVars, name string) []string {
+	result := make([]string, 0)
+	if vars.VarsType() == Positions {
+		if pos, err := strconv.Atoi(name); err == nil {
+			if v, ok := vars.GetPositional(pos); ok {
+				result = append(result, v)
+			}
+		}
+		return result
+	}
+	for i := 0; ; i++ {
+		if v, ok := vars.GetNamed(name, i); ok {
+			result = append(result, v)
+		} else {
+			break
+		}
+	}
+	return result
+}
+
+func bindIdent(vars PathVars, name string) []interface{} {
+	if vars.VarsType() == Positions {
+		if pos, err := strconv.Atoi(name); err == nil {
+			return []interface{}{pos}
+		}
+	}
+	return []interface{}{name}
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// isBindScalar determines whether a slice type is converted as a single value (e.g. []byte or a TextUnmarshaler slice type)
+func isBindScalar(t reflect.Type) bool {
+	return t.Elem().Kind() == reflect.Uint8 || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// convertVar converts the string value into the (settable) reflect value
+func convertVar(s string, rv reflect.Value, layout string) error {
+	if rv.Kind() == reflect.Ptr {
+		nv := reflect.New(rv.Type().Elem())
+		if err := convertVar(s, nv.Elem(), layout); err != nil {
+			return err
+		}
+		rv.Set(nv)
+		return nil
+	}
+	switch rv.Type() {
+	case timeType:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tv, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(tv))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes([]byte(s))
+			return nil
+		}
+		fallthrough
+	default:
+		return errors.New("unsupported type " + rv.Type().String())
+	}
+	return nil
+}
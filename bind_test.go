@@ -0,0 +1,200 @@
+package urit
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type bindBase struct {
+	Tenant string `urit:"tenant"`
+}
+
+type bindTarget struct {
+	bindBase
+	Year     int           `urit:"year"`
+	Month    uint8         `urit:"month"`
+	Ratio    float64       `urit:"ratio"`
+	Active   bool          `urit:"active"`
+	At       time.Time     `urit:"at"`
+	Day      time.Time     `urit:"day,layout=2006-01-02"`
+	Timeout  time.Duration `urit:"timeout"`
+	Ip       net.IP        `urit:"ip"`
+	Ids      []int         `urit:"id"`
+	Name     *string       `urit:"name"`
+	Count    *int          `urit:"count"`
+	Default  string        `urit:""`
+	Ignored  string        `urit:"-"`
+	Untagged string
+	Missing  string `urit:"missing"`
+}
+
+func TestBind(t *testing.T) {
+	tmp := MustCreateTemplate(`/{tenant}/{year}/{month}/{ratio}/{active}/{at}/{day}/{timeout}/{ip}/{id}/{id}/{name}/{count}/{Default}/{-}`)
+	vars, ok := tmp.Matches(`/acme/2022/11/0.5/true/2022-11-01T10:20:30Z/2022-11-02/1m30s/127.0.0.1/1/2/bilbo/3/def/ign`)
+	require.True(t, ok)
+
+	dst := &bindTarget{Missing: "unchanged"}
+	err := vars.Bind(dst)
+	require.NoError(t, err)
+	require.Equal(t, "acme", dst.Tenant)
+	require.Equal(t, 2022, dst.Year)
+	require.Equal(t, uint8(11), dst.Month)
+	require.Equal(t, 0.5, dst.Ratio)
+	require.True(t, dst.Active)
+	require.Equal(t, time.Date(2022, 11, 1, 10, 20, 30, 0, time.UTC), dst.At)
+	require.Equal(t, time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC), dst.Day)
+	require.Equal(t, 90*time.Second, dst.Timeout)
+	require.Equal(t, "127.0.0.1", dst.Ip.String())
+	require.Equal(t, []int{1, 2}, dst.Ids)
+	require.Equal(t, "bilbo", *dst.Name)
+	require.Equal(t, 3, *dst.Count)
+	require.Equal(t, "def", dst.Default)
+	require.Equal(t, "", dst.Ignored)
+	require.Equal(t, "", dst.Untagged)
+	require.Equal(t, "unchanged", dst.Missing)
+}
+
+func TestBind_Positional(t *testing.T) {
+	tmp := MustCreateTemplate(`/credits/?/?`)
+	vars, ok := tmp.Matches(`/credits/2022/11`)
+	require.True(t, ok)
+	dst := &struct {
+		Year  int    `urit:"0"`
+		Month int    `urit:"1"`
+		Other string `urit:"2"`
+		Named string `urit:"named"`
+	}{}
+	err := vars.Bind(dst)
+	require.NoError(t, err)
+	require.Equal(t, 2022, dst.Year)
+	require.Equal(t, 11, dst.Month)
+	require.Equal(t, "", dst.Other)
+	require.Equal(t, "", dst.Named)
+}
+
+func TestBind_Errors(t *testing.T) {
+	err := Bind(Named(), nil)
+	require.Error(t, err)
+	require.Equal(t, `bind destination must be a non-nil pointer to a struct`, err.Error())
+	err = Bind(Named(), struct{}{})
+	require.Error(t, err)
+	str := ""
+	err = Bind(Named(), &str)
+	require.Error(t, err)
+	require.NoError(t, Bind(nil, &struct{}{}))
+
+	testCases := []struct {
+		vars      PathVars
+		dst       interface{}
+		expectErr string
+	}{
+		{
+			vars: Named("year", "abc"),
+			dst: &struct {
+				Year int `urit:"year"`
+			}{},
+			expectErr: `cannot convert path var 'year' value 'abc' for field 'Year': strconv.ParseInt: parsing "abc": invalid syntax`,
+		},
+		{
+			vars: Named("month", "256"),
+			dst: &struct {
+				Month uint8 `urit:"month"`
+			}{},
+			expectErr: `cannot convert path var 'month' value '256' for field 'Month': strconv.ParseUint: parsing "256": value out of range`,
+		},
+		{
+			vars: Named("ratio", "x"),
+			dst: &struct {
+				Ratio float32 `urit:"ratio"`
+			}{},
+			expectErr: `cannot convert path var 'ratio' value 'x' for field 'Ratio': strconv.ParseFloat: parsing "x": invalid syntax`,
+		},
+		{
+			vars: Named("active", "x"),
+			dst: &struct {
+				Active bool `urit:"active"`
+			}{},
+			expectErr: `cannot convert path var 'active' value 'x' for field 'Active': strconv.ParseBool: parsing "x": invalid syntax`,
+		},
+		{
+			vars: Named("at", "x"),
+			dst: &struct {
+				At time.Time `urit:"at"`
+			}{},
+			expectErr: `cannot convert path var 'at' value 'x' for field 'At': parsing time "x" as "2006-01-02T15:04:05Z07:00": cannot parse "x" as "2006"`,
+		},
+		{
+			vars: Named("timeout", "x"),
+			dst: &struct {
+				Timeout time.Duration `urit:"timeout"`
+			}{},
+			expectErr: `cannot convert path var 'timeout' value 'x' for field 'Timeout': time: invalid duration "x"`,
+		},
+		{
+			vars: Named("ip", "x"),
+			dst: &struct {
+				Ip net.IP `urit:"ip"`
+			}{},
+			expectErr: `cannot convert path var 'ip' value 'x' for field 'Ip': invalid IP address: x`,
+		},
+		{
+			vars: Named("id", "1", "id", "x"),
+			dst: &struct {
+				Ids []*int `urit:"id"`
+			}{},
+			expectErr: `cannot convert path var 'id' value 'x' for field 'Ids': strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			vars: Named("m", "x"),
+			dst: &struct {
+				M map[string]string `urit:"m"`
+			}{},
+			expectErr: `cannot convert path var 'm' value 'x' for field 'M': unsupported type map[string]string`,
+		},
+		{
+			vars: Positional("x"),
+			dst: &struct {
+				bindBase
+				Id int `urit:"0"`
+			}{},
+			expectErr: `cannot convert path var '0' value 'x' for field 'Id': strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			vars: Named("tenant", 1, "x", 2),
+			dst: &struct {
+				Base struct {
+					Tenant int `urit:"tenant"`
+				}
+				bindBase
+				Id struct{} `urit:"x"`
+			}{},
+			expectErr: `cannot convert path var 'x' value '2' for field 'Id': unsupported type struct {}`,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
+			err := Bind(tc.vars, tc.dst)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+			var cErr VarConversionError
+			require.True(t, errors.As(err, &cErr))
+			require.NotEmpty(t, cErr.Name())
+			require.NotEmpty(t, cErr.Value())
+			require.NotEmpty(t, cErr.Field())
+			require.NotNil(t, cErr.Unwrap())
+		})
+	}
+}
+
+func TestBind_Bytes(t *testing.T) {
+	dst := &struct {
+		Data []byte `urit:"data"`
+	}{}
+	err := Named("data", "abc", "data", "def").Bind(dst)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), dst.Data)
+}
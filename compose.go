@@ -0,0 +1,77 @@
+package urit
+
+import (
+	"errors"
+	"strings"
+)
+
+// Join joins two templates - the path (and any query params) of template b are appended to template a
+//
+// The same rules as Template.Sub apply (e.g. template b cannot have a host and the templates cannot contain
+// both positional and named path vars) - and the new template has the options of both templates
+func Join(a, b Template) (Template, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("cannot join nil template")
+	}
+	_, aok := a.(*template)
+	_, bok := b.(*template)
+	if aok != bok {
+		return nil, errors.New("cannot join templates of different types")
+	}
+	return a.Sub(b.OriginalTemplate(), b.Options()...)
+}
+
+// Prefix generates a new template with the base path prefixed (after any host)
+//
+// the base path is parsed with the template options followed by any options specified - and the new template has
+// the options of both
+func (t *template) Prefix(base string, options ...interface{}) (Template, error) {
+	base = strings.TrimRight(slashPrefix(strings.Trim(base, " ")), "/")
+	if base == "" {
+		return t, nil
+	}
+	bt, err := NewTemplate(base, options...)
+	if err != nil {
+		return nil, err
+	}
+	rb := bt.(*template)
+	if rb.host != nil {
+		return nil, newTemplateParseError("prefix cannot contain host", 0, nil)
+	} else if len(rb.queryParts) > 0 {
+		return nil, newTemplateParseError("prefix cannot contain query params", 0, nil)
+	} else if rb.hasOptionalGroups() {
+		return nil, newTemplateParseError("prefix cannot contain optional groups", 0, nil)
+	} else if rb.hasCatchAll() {
+		return nil, newTemplateParseError("prefix cannot contain catch-all var", 0, nil)
+	}
+	_, authority, aPos, pth := splitHostTemplate(t.originalTemplate)
+	host := ""
+	if aPos != -1 {
+		host = t.originalTemplate[:aPos+len(authority)]
+	}
+	if pth == "/" {
+		pth = ""
+	}
+	return NewTemplate(host+base+pth, append(t.Options(), options...)...)
+}
+
+// Prefix generates a new template with the base path prefixed
+//
+// the base path is parsed with the template options followed by any options specified - and the new template has
+// the options of both
+func (t *rfc6570Template) Prefix(base string, options ...interface{}) (Template, error) {
+	if t.absolute {
+		return nil, newTemplateParseError("cannot prefix absolute template", 0, nil)
+	}
+	return NewRfc6570Template(strings.TrimRight(slashPrefix(strings.Trim(base, " ")), "/")+t.originalTemplate, append(t.Options(), options...)...)
+}
+
+// StripPrefix matches the start of the path against the prefix template - and if the prefix matches, returns the
+// remainder of the path (escaped and always starting with "/") and the path vars extracted by the prefix
+// (same as Template.MatchesPrefix)
+//
+// Useful for mounting handlers (or routers) under a prefix (see ServeMux.Mount)
+func StripPrefix(path string, prefix Template, options ...interface{}) (string, PathVars, bool) {
+	vars, remainder, ok := prefix.MatchesPrefix(path, options...)
+	return remainder, vars, ok
+}
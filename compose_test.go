@@ -0,0 +1,145 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	a := MustCreateTemplate(`/API/{version}`, CaseInsensitiveFixed)
+	b := MustCreateTemplate(`/orders/{id:int}{?fields}`, PathRegexCheck)
+	j, err := Join(a, b)
+	require.NoError(t, err)
+	require.Equal(t, `/API/{version}/orders/{id:int}{?fields}`, j.OriginalTemplate())
+	require.Equal(t, []interface{}{CaseInsensitiveFixed, PathRegexCheck}, j.Options())
+	vars, ok := j.Matches(`/api/v1/ORDERS/123?fields=a`)
+	require.True(t, ok)
+	id, _ := vars.GetInt("id")
+	require.Equal(t, 123, id)
+
+	j, err = Join(MustCreateTemplate(`https://example.com/api`), b)
+	require.NoError(t, err)
+	require.Equal(t, `https://example.com/api/orders/{id:int}{?fields}`, j.OriginalTemplate())
+
+	j, err = Join(MustCreateRfc6570Template(`/api`), MustCreateRfc6570Template(`/users{/id}`))
+	require.NoError(t, err)
+	require.Equal(t, `/api/users{/id}`, j.OriginalTemplate())
+
+	testCases := []struct {
+		a         Template
+		b         Template
+		expectErr string
+	}{
+		{nil, b, "cannot join nil template"},
+		{a, nil, "cannot join nil template"},
+		{a, MustCreateRfc6570Template(`/users{/id}`), "cannot join templates of different types"},
+		{a, MustCreateTemplate(`https://example.com/foo`), "sub-path cannot contain host"},
+		{a, MustCreateTemplate(`/foo/?`), "template cannot contain both positional and named path variables"},
+		{MustCreateTemplate(`/foo[/{id}]`), b, "cannot add sub-path to template with optional groups"},
+	}
+	for _, tc := range testCases {
+		_, err = Join(tc.a, tc.b)
+		require.Error(t, err)
+		require.Equal(t, tc.expectErr, err.Error())
+	}
+}
+
+func TestTemplate_Prefix(t *testing.T) {
+	tmp := MustCreateTemplate(`/orders/{id}{?fields}`, CaseInsensitiveFixed)
+	p, err := tmp.Prefix(`/api/{version}/`)
+	require.NoError(t, err)
+	require.Equal(t, `/api/{version}/orders/{id}{?fields}`, p.OriginalTemplate())
+	require.Equal(t, []interface{}{CaseInsensitiveFixed}, p.Options())
+	_, ok := p.Matches(`/API/v1/Orders/123`)
+	require.True(t, ok)
+
+	p, err = tmp.Prefix(`api`)
+	require.NoError(t, err)
+	require.Equal(t, `/api/orders/{id}{?fields}`, p.OriginalTemplate())
+
+	p, err = tmp.Prefix(`/`)
+	require.NoError(t, err)
+	require.Equal(t, tmp, p)
+
+	p, err = MustCreateTemplate(`https://{sub}.example.com/orders`).Prefix(`/api`)
+	require.NoError(t, err)
+	require.Equal(t, `https://{sub}.example.com/api/orders`, p.OriginalTemplate())
+	_, ok = p.Matches(`https://www.example.com/api/orders`)
+	require.True(t, ok)
+
+	p, err = MustCreateTemplate(`/`).Prefix(`/api`)
+	require.NoError(t, err)
+	require.Equal(t, `/api`, p.OriginalTemplate())
+
+	p, err = MustCreateRfc6570Template(`/users{/id}`).Prefix(`/api/`)
+	require.NoError(t, err)
+	require.Equal(t, `/api/users{/id}`, p.OriginalTemplate())
+	_, err = MustCreateRfc6570Template(`{+base}/users`).Prefix(`/api`)
+	require.Error(t, err)
+
+	testCases := []struct {
+		base      string
+		expectErr string
+	}{
+		{`https://example.com/api`, "prefix cannot contain host"},
+		{`/api{?q}`, "prefix cannot contain query params"},
+		{`/api[/{v}]`, "prefix cannot contain optional groups"},
+		{`/api/*`, "prefix cannot contain catch-all var"},
+		{`/api/?`, "template cannot contain both positional and named path variables"},
+		{`/api/{`, "unclosed '{' at position 5"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.base, func(t *testing.T) {
+			_, err := tmp.Prefix(tc.base)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	prefix := MustCreateTemplate(`/api/{version:v[0-9]+}`)
+	testCases := []struct {
+		path            string
+		expectOk        bool
+		expectRemainder string
+		expectVersion   string
+	}{
+		{`/api/v1/orders/123`, true, `/orders/123`, "v1"},
+		{`/api/v1/orders/123/`, true, `/orders/123/`, "v1"},
+		{`/api/v1/orders/a%2Fb?q=1`, true, `/orders/a%2Fb`, "v1"},
+		{`/api/v1`, true, `/`, "v1"},
+		{`/api/v1/`, true, `/`, "v1"},
+		{`/api/x1/orders`, false, ``, ""},
+		{`/api`, false, ``, ""},
+		{`/other/v1/orders`, false, ``, ""},
+		{`/api//v1`, false, ``, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			remainder, vars, ok := StripPrefix(tc.path, prefix)
+			require.Equal(t, tc.expectOk, ok)
+			require.Equal(t, tc.expectRemainder, remainder)
+			if ok {
+				v, _ := vars.Get("version")
+				require.Equal(t, tc.expectVersion, v)
+			}
+		})
+	}
+
+	prefix = MustCreateTemplate(`/api[/{version:v[0-9]+}]`)
+	remainder, vars, ok := StripPrefix(`/api/v1/orders`, prefix)
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	require.Equal(t, 1, vars.Len())
+	remainder, vars, ok = StripPrefix(`/api/orders`, prefix)
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	require.Equal(t, 0, vars.Len())
+
+	remainder, _, ok = StripPrefix(`/api/orders`, MustCreateRfc6570Template(`/api`))
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	_, _, ok = StripPrefix("/api/\x7f", prefix)
+	require.False(t, ok)
+}
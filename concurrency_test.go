@@ -0,0 +1,115 @@
+package urit
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+// these tests are most useful when run with the race detector (i.e. go test -race)
+//
+// note: the fn callbacks run on other goroutines - so must use assert (not require, which calls t.FailNow)
+
+func runConcurrently(t *testing.T, fn func(t *testing.T, g int, i int)) {
+	const goroutines = 16
+	const iterations = 50
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				fn(t, g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestTemplate_Concurrent(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{sub}.example.com/orders/{id:int}/items/{name}.{ext}[/{version}]{?q}`)
+	original := tmp.OriginalTemplate()
+	runConcurrently(t, func(t *testing.T, g int, i int) {
+		pth := fmt.Sprintf(`https://api.example.com/orders/%d/items/foo-%d.txt`, g, i)
+		vars, ok := tmp.Matches(pth)
+		if !assert.True(t, ok) {
+			return
+		}
+		id, _ := vars.GetInt("id")
+		assert.Equal(t, g, id)
+
+		r := tmp.MatchDetailed(`https://api.example.com/orders/99999999999999999999/items/foo.txt`)
+		assert.False(t, r.Matched)
+		assert.Equal(t, MismatchVarType, r.Reason)
+
+		built, err := tmp.PathFrom(vars)
+		assert.NoError(t, err)
+		assert.Equal(t, pth, built)
+
+		sub, err := tmp.Sub(`/{extra}`)
+		assert.Error(t, err) // has optional groups
+		assert.Nil(t, sub)
+
+		rt, err := tmp.ResolveTo(Named("sub", "api", "id", g))
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, ok = rt.Matches(pth)
+		assert.True(t, ok)
+	})
+	require.Equal(t, original, tmp.OriginalTemplate())
+}
+
+func TestTemplate_Concurrent_Sub(t *testing.T) {
+	tmp := MustCreateTemplate(`/orders/{id}/{a}-{b}`, NonGreedySubParts)
+	runConcurrently(t, func(t *testing.T, g int, i int) {
+		sub, err := tmp.Sub(fmt.Sprintf(`/items/{item%d}`, g))
+		if !assert.NoError(t, err) {
+			return
+		}
+		vars, ok := sub.Matches(`/orders/1/x-y-z/items/2`)
+		if !assert.True(t, ok) {
+			return
+		}
+		a, _ := vars.Get("a")
+		assert.Equal(t, "x", a)
+		_, ok = vars.Get(fmt.Sprintf("item%d", g))
+		assert.True(t, ok)
+
+		_, ok = tmp.Matches(`/orders/1/x-y-z/items/2`)
+		assert.False(t, ok)
+		pth, err := tmp.PathFrom(Named("id", i, "a", "x", "b", "y"))
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`/orders/%d/x-y`, i), pth)
+	})
+	require.Len(t, tmp.Vars(), 3)
+}
+
+func TestRfc6570Template_Concurrent(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}{?fields}`)
+	runConcurrently(t, func(t *testing.T, g int, i int) {
+		vars, ok := tmp.Matches(fmt.Sprintf(`/users/%d?fields=a`, g))
+		if !assert.True(t, ok) {
+			return
+		}
+		id, _ := vars.Get("id")
+		assert.Equal(t, fmt.Sprintf("%d", g), id)
+		pth, err := tmp.PathFrom(vars)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`/users/%d?fields=a`, g), pth)
+	})
+}
+
+func TestRouter_Concurrent(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/orders/{id}`), "order"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/orders/{id}/{name}.{ext}`), "file"))
+	runConcurrently(t, func(t *testing.T, g int, i int) {
+		m, ok := r.Match(fmt.Sprintf(`/orders/%d/foo.txt`, g))
+		if assert.True(t, ok) {
+			assert.Equal(t, "file", m.Payload)
+		}
+	})
+}
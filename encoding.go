@@ -0,0 +1,49 @@
+package urit
+
+import "strings"
+
+const upperHex = "0123456789ABCDEF"
+
+// escapePathSegment percent-encodes a value for use as a path segment (RFC 3986 section 3.3)
+//
+// i.e. all characters except unreserved, sub-delims, ':' and '@' are percent-encoded
+func escapePathSegment(s string) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if !isPathSegmentChar(s[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) + 2*n)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; isPathSegmentChar(c) {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(upperHex[c>>4])
+			sb.WriteByte(upperHex[c&15])
+		}
+	}
+	return sb.String()
+}
+
+func isUnreservedChar(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isSubDelimChar(c byte) bool {
+	switch c {
+	case '!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=':
+		return true
+	}
+	return false
+}
+
+func isPathSegmentChar(c byte) bool {
+	return isUnreservedChar(c) || isSubDelimChar(c) || c == ':' || c == '@'
+}
@@ -0,0 +1,41 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEscapePathSegment(t *testing.T) {
+	testCases := []struct {
+		value  string
+		expect string
+	}{
+		{``, ``},
+		{`abc`, `abc`},
+		{`a/b c`, `a%2Fb%20c`},
+		{`100%`, `100%25`},
+		{`a?b#c`, `a%3Fb%23c`},
+		{`-._~!$&'()*+,;=:@`, `-._~!$&'()*+,;=:@`},
+		{`café`, `caf%C3%A9`},
+		{`[x]{y}`, `%5Bx%5D%7By%7D`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			require.Equal(t, tc.expect, escapePathSegment(tc.value))
+		})
+	}
+}
+
+func TestEncodingOptions(t *testing.T) {
+	require.Equal(t, `a%2Fb`, _PercentEncoded.Encode(`a/b`))
+	s, ok := _PercentEncoded.Decode(`a%2Fb`)
+	require.True(t, ok)
+	require.Equal(t, `a/b`, s)
+	_, ok = _PercentEncoded.Decode(`100%`)
+	require.False(t, ok)
+
+	require.Equal(t, `a/b`, PreEncoded.Encode(`a/b`))
+	s, ok = PreEncoded.Decode(`a%2Fb`)
+	require.True(t, ok)
+	require.Equal(t, `a%2Fb`, s)
+}
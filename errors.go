@@ -1,5 +1,7 @@
 package urit
 
+import "fmt"
+
 type TemplateParseError interface {
 	error
 	Unwrap() error
@@ -31,3 +33,58 @@ func (e *templateParseError) Unwrap() error {
 func (e *templateParseError) Position() int {
 	return e.pos
 }
+
+// VarConversionError is the error returned when a path var value cannot be converted (e.g. when binding path vars into a struct)
+type VarConversionError interface {
+	error
+	Unwrap() error
+	// Name returns the name of the path var (or the position, as a string, for positional path vars)
+	Name() string
+	// Value returns the path var value that could not be converted
+	Value() string
+	// Field returns the name of the struct field being bound (empty if not binding)
+	Field() string
+}
+
+func newVarConversionError(name string, value string, field string, err error) VarConversionError {
+	return &varConversionError{
+		name:  name,
+		value: value,
+		field: field,
+		err:   err,
+	}
+}
+
+type varConversionError struct {
+	name  string
+	value string
+	field string
+	err   error
+}
+
+func (e *varConversionError) Error() string {
+	msg := fmt.Sprintf("cannot convert path var '%s' value '%s'", e.name, e.value)
+	if e.field != "" {
+		msg += fmt.Sprintf(" for field '%s'", e.field)
+	}
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *varConversionError) Unwrap() error {
+	return e.err
+}
+
+func (e *varConversionError) Name() string {
+	return e.name
+}
+
+func (e *varConversionError) Value() string {
+	return e.value
+}
+
+func (e *varConversionError) Field() string {
+	return e.field
+}
@@ -0,0 +1,118 @@
+package urit
+
+import "strings"
+
+// precompile compiles the overall regexps of any path parts (and host parts) with multiple sub-parts - so that
+// templates are not modified when matching (and are therefore safe for concurrent use)
+func (t *template) precompile() error {
+	for i := range t.pathParts {
+		if err := t.pathParts[i].precompile(); err != nil {
+			return err
+		}
+	}
+	for _, pt := range t.host.parts() {
+		if err := pt.precompile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pt *pathPart) precompile() error {
+	if !pt.fixed && len(pt.subParts) > 0 && pt.allRegexp == nil {
+		if pt.allRegexp, pt.allRegexpIdxs = pt.compileOverallRegexp(); pt.allRegexp == nil {
+			return newTemplateParseError("path part regexp problem", 0, nil)
+		}
+	}
+	return nil
+}
+
+// splitPath splits an (escaped) URL path into segments - ignoring any leading or trailing "/"
+//
+// returns false if there are any empty segments (e.g. `/foo//bar`)
+func splitPath(s string) ([]string, bool) {
+	body, ok := pathBody(s)
+	if !ok {
+		return nil, false
+	} else if body == "" {
+		return []string{}, true
+	}
+	result := make([]string, 0, strings.Count(body, "/")+1)
+	for {
+		i := strings.IndexByte(body, '/')
+		if i == -1 {
+			return append(result, body), true
+		} else if i == 0 {
+			return nil, false
+		}
+		result = append(result, body[:i])
+		body = body[i+1:]
+		if body == "" {
+			return nil, false
+		}
+	}
+}
+
+// pathBody returns the path without any leading or trailing "/" (returns false if the path is only empty segments - e.g. `//`)
+func pathBody(s string) (string, bool) {
+	if s == "" || s == "/" {
+		return "", true
+	}
+	if s[0] == '/' {
+		s = s[1:]
+	}
+	if s != "" && s[len(s)-1] == '/' {
+		s = s[:len(s)-1]
+	}
+	return s, s != ""
+}
+
+// quickReject determines, without allocating, whether a path string definitely does not match the template
+//
+// it is conservative - only paths (without scheme or host) that have a disallowed number of segments or a segment that
+// cannot match its path part are rejected (a false result does not mean that the path matches)
+func (t *template) quickReject(path string) bool {
+	if t.host != nil || !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return false
+	}
+	if i := strings.IndexAny(path, "?#"); i != -1 {
+		path = path[:i]
+	}
+	body, ok := pathBody(path)
+	if !ok {
+		return true
+	} else if body == "" {
+		return !t.allowsSegmentCount(0)
+	}
+	for n := 0; ; n++ {
+		seg := body
+		i := strings.IndexByte(body, '/')
+		if i != -1 {
+			seg, body = body[:i], body[i+1:]
+		}
+		if seg == "" || t.rejectsSegment(n, seg) {
+			return true
+		} else if i == -1 {
+			return !t.allowsSegmentCount(n + 1)
+		}
+	}
+}
+
+// rejectsSegment determines whether the (raw) path segment definitely does not match the path part at the index
+func (t *template) rejectsSegment(i int, seg string) bool {
+	if i >= len(t.pathParts) && !t.hasCatchAll() {
+		return true
+	} else if strings.IndexByte(seg, '%') != -1 {
+		// encoded segments are only checked when fully matching...
+		return false
+	}
+	pt := t.partAt(i)
+	if pt.fixed {
+		return len(t.fixedMatchOpts) == 0 && seg != pt.fixedValue && seg != pt.decodedFixedValue()
+	} else if len(t.varMatchOpts) > 0 {
+		return false
+	} else if len(pt.subParts) > 0 {
+		return pt.allRegexp != nil && !pt.allRegexp.MatchString(seg)
+	}
+	return pt.regexp != nil && !pt.regexp.MatchString(seg)
+}
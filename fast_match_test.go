@@ -0,0 +1,148 @@
+package urit
+
+import (
+	"github.com/go-andiamo/splitter"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	// should behave the same as the splitter previously used for matching...
+	sp := splitter.MustCreateSplitter('/').
+		AddDefaultOptions(splitter.IgnoreEmptyFirst, splitter.IgnoreEmptyLast, splitter.NotEmptyInners)
+	testCases := []string{
+		"", "/", "//", "///", "a", "/a", "a/", "/a/", "//a", "a//",
+		"/a/b", "/a/b/", "/a//b", "/a/b//", "/a/b/c", "a/b/c", "/%2F/b",
+	}
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			expect, err := sp.Split(tc)
+			pts, ok := splitPath(tc)
+			require.Equal(t, err == nil, ok)
+			if ok {
+				require.Equal(t, expect, pts)
+			}
+		})
+	}
+}
+
+func TestTemplate_QuickReject(t *testing.T) {
+	templates := []Template{
+		MustCreateTemplate(`/`),
+		MustCreateTemplate(`/foo/{id}`),
+		MustCreateTemplate(`/foo/{id:[0-9]+}/bar`),
+		MustCreateTemplate(`/foo/{name}.{ext:(txt|csv)}`),
+		MustCreateTemplate(`/foo%20bar/{id}`),
+		MustCreateTemplate(`/orders[/{id}[/{item}]]`),
+		MustCreateTemplate(`/files/{path...:[a-z]+}`),
+		MustCreateTemplate(`/FOO/{id}`, CaseInsensitiveFixed),
+		MustCreateTemplate(`/foo/{id}{?q!}`),
+		MustCreateTemplate(`https://{sub}.example.com/foo`),
+	}
+	paths := []string{
+		``, `/`, `//`, `/foo`, `/foo/`, `/foo/123`, `/foo/123/`, `/foo/abc/bar`, `/foo/123/bar`, `/foo/123/bar/baz`,
+		`/foo/file.txt`, `/foo/file.doc`, `/foo%20bar/1`, `/foo bar/1`, `/orders`, `/orders/1`, `/orders/1/2`, `/orders/1/2/3`,
+		`/files/a/b`, `/files/a/1`, `/files`, `/foo/123?q=1`, `/foo/123#frag`, `/foo//123`, `/f%6Fo/123`,
+		`https://api.example.com/foo`, `foo/123`,
+	}
+	for _, tmp := range templates {
+		rt := tmp.(*template)
+		for _, pth := range paths {
+			if rt.quickReject(pth) {
+				// a rejected path must not match when fully matched...
+				u, err := url.Parse(pth)
+				if err == nil {
+					_, ok := rt.matchesUrl(u, nil)
+					require.False(t, ok, "template %q, path %q", tmp.OriginalTemplate(), pth)
+				}
+			}
+		}
+	}
+
+	rt := MustCreateTemplate(`/foo/{id:[0-9]+}/bar`).(*template)
+	require.True(t, rt.quickReject(`/foo`))
+	require.True(t, rt.quickReject(`/bar/123/bar`))
+	require.True(t, rt.quickReject(`/foo/abc/bar`))
+	require.True(t, rt.quickReject(`/foo/123/bar/baz`))
+	require.False(t, rt.quickReject(`/foo/123/bar`))
+	require.False(t, rt.quickReject(`/foo/%31/bar`))
+}
+
+var raceEnabled = false
+
+func TestTemplate_Matches_NoAllocsWhenNotMatching(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not deterministic with race detector")
+	}
+	tmp := MustCreateTemplate(`/api/{version:v[0-9]+}/orders/{id}/items/{name}.{ext}`)
+	for _, pth := range []string{`/api/v1/customers/123`, `/api/x1/orders/123/items/a.b`, `/api/v1/orders/123/items/ab`, `/other`} {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = tmp.Matches(pth)
+		})
+		require.Equal(t, float64(0), allocs, pth)
+		u, _ := url.Parse(pth)
+		allocs = testing.AllocsPerRun(100, func() {
+			_, _ = tmp.MatchesUrl(*u)
+		})
+		require.Equal(t, float64(0), allocs, pth)
+		req, _ := http.NewRequest(http.MethodGet, `http://example.com`+pth, nil)
+		allocs = testing.AllocsPerRun(100, func() {
+			_, _ = tmp.MatchesRequest(req)
+		})
+		require.Equal(t, float64(0), allocs, pth)
+	}
+}
+
+func BenchmarkTemplate_Matches(b *testing.B) {
+	tmp := MustCreateTemplate(`/api/{version:v[0-9]+}/orders/{id}/items/{name}.{ext}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = tmp.Matches(`/api/v1/orders/123/items/foo.txt`)
+	}
+}
+
+func BenchmarkTemplate_Matches_NotMatching(b *testing.B) {
+	tmp := MustCreateTemplate(`/api/{version:v[0-9]+}/orders/{id}/items/{name}.{ext}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = tmp.Matches(`/api/v1/customers/123`)
+	}
+}
+
+func BenchmarkTemplate_MatchesUrl_NotMatching(b *testing.B) {
+	tmp := MustCreateTemplate(`/api/{version:v[0-9]+}/orders/{id}/items/{name}.{ext}`)
+	u, _ := url.Parse(`/api/v1/customers/123`)
+	b.Run("quick reject", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = tmp.MatchesUrl(*u)
+		}
+	})
+	// without the quick reject (i.e. as MatchesUrl was before it used the fast path)...
+	rt := tmp.(*template)
+	b.Run("full match", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cu := *u
+			_, _ = rt.matchesUrl(&cu, nil)
+		}
+	})
+}
+
+func BenchmarkTemplate_MatchesRequest_NotMatching(b *testing.B) {
+	tmp := MustCreateTemplate(`/api/{version:v[0-9]+}/orders/{id}/items/{name}.{ext}`)
+	req, _ := http.NewRequest(http.MethodGet, `http://example.com/api/v1/customers/123`, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = tmp.MatchesRequest(req)
+	}
+}
+
+func BenchmarkSplitPath(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = splitPath(`/api/v1/orders/123/items/foo.txt`)
+	}
+}
@@ -0,0 +1,270 @@
+package urit
+
+import (
+	"github.com/go-andiamo/splitter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// hostTemplate is the scheme, host and (optional) port of a template - e.g. `https://{tenant}.api.example.com:{port}`
+//
+// host labels (split on '.') are treated like path segments - each can be fixed, a var or a mix of fixed and vars
+type hostTemplate struct {
+	scheme pathPart
+	labels []pathPart
+	port   *pathPart
+}
+
+// splitHostTemplate splits a template into the scheme, authority (host and port) and the remaining path -
+// if the template does not start with a scheme, the authority is empty
+func splitHostTemplate(s string) (scheme string, authority string, authPos int, path string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case c == '{' || c == '(':
+			depth++
+		case c == '}' || c == ')':
+			depth--
+		case depth == 0 && c == '/':
+			return "", "", -1, s
+		case depth == 0 && c == ':' && strings.HasPrefix(s[i:], "://"):
+			authPos = i + 3
+			end := len(s)
+			for j := authPos; j < len(s) && end == len(s); j++ {
+				switch c := s[j]; {
+				case c == '\\':
+					j++
+				case c == '{' && depth == 0 && strings.HasPrefix(s[j:], "{?"):
+					end = j
+				case c == '{' || c == '(':
+					depth++
+				case c == '}' || c == ')':
+					depth--
+				case depth == 0 && c == '/':
+					end = j
+				}
+			}
+			return s[:i], s[authPos:end], authPos, s[end:]
+		}
+	}
+	return "", "", -1, s
+}
+
+// hasHostTemplate determines whether the template starts with a scheme (e.g. `https://`)
+func hasHostTemplate(s string) bool {
+	_, _, pos, _ := splitHostTemplate(s)
+	return pos != -1
+}
+
+func (t *template) parseHost(scheme string, authority string, pos int) error {
+	if scheme == "" {
+		return newTemplateParseError("template scheme cannot be empty", 0, nil)
+	} else if authority == "" {
+		return newTemplateParseError("template host cannot be empty", pos, nil)
+	}
+	h := &hostTemplate{}
+	parts, err := t.parseHostParts(scheme, 0)
+	if err != nil {
+		return err
+	} else if len(parts) != 1 {
+		return newTemplateParseError("invalid template scheme", 0, nil)
+	}
+	h.scheme = parts[0]
+	hostname := authority
+	if cAt := lastPortColon(authority); cAt != -1 {
+		hostname = authority[:cAt]
+		if parts, err = t.parseHostParts(authority[cAt+1:], pos+cAt+1); err != nil {
+			return err
+		} else if len(parts) != 1 {
+			return newTemplateParseError("invalid template port", pos+cAt+1, nil)
+		}
+		h.port = &parts[0]
+	}
+	if h.labels, err = t.parseHostParts(hostname, pos); err != nil {
+		return err
+	}
+	t.host = h
+	return nil
+}
+
+// lastPortColon finds the position of the ':' separating the host and port (ignoring any within var brackets)
+func lastPortColon(authority string) int {
+	result := -1
+	depth := 0
+	for i := 0; i < len(authority); i++ {
+		switch c := authority[i]; {
+		case c == '\\':
+			i++
+		case c == '{' || c == '(':
+			depth++
+		case c == '}' || c == ')':
+			depth--
+		case c == ':' && depth == 0:
+			result = i
+		}
+	}
+	return result
+}
+
+var hostSplitter = splitter.MustCreateSplitter('.',
+	splitter.MustMakeEscapable(splitter.Parenthesis, '\\'),
+	splitter.MustMakeEscapable(splitter.CurlyBrackets, '\\'),
+	splitter.DoubleQuotesBackSlashEscaped, splitter.SingleQuotesBackSlashEscaped).
+	AddDefaultOptions(splitter.NoEmptiesMsg("host parts cannot be empty"))
+
+type hostPartCapture struct {
+	template *template
+	parts    []pathPart
+	offset   int
+}
+
+func (c *hostPartCapture) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...splitter.SubPart) (string, bool, error) {
+	pt, err := c.template.newUriPathPart(s, pos, subParts)
+	if err != nil {
+		return "", false, err
+	} else if pt.catchAll {
+		return "", false, newTemplateParseError("catch-all var cannot be used in template host", c.offset+pos, nil)
+	}
+	// hosts (and schemes) are case-insensitive...
+	pt.lowerFixed()
+	c.parts = append(c.parts, pt)
+	return s, true, nil
+}
+
+func (t *template) parseHostParts(s string, offset int) ([]pathPart, error) {
+	capture := &hostPartCapture{template: t, offset: offset}
+	if _, err := hostSplitter.Split(s, capture); err != nil {
+		return nil, err
+	}
+	return capture.parts, nil
+}
+
+func (pt *pathPart) lowerFixed() {
+	pt.fixedValue = strings.ToLower(pt.fixedValue)
+	for i := range pt.subParts {
+		pt.subParts[i].lowerFixed()
+	}
+}
+
+// parts returns all the host template parts (scheme, host labels and port) in order
+func (h *hostTemplate) parts() []*pathPart {
+	if h == nil {
+		return nil
+	}
+	result := make([]*pathPart, 0, len(h.labels)+2)
+	result = append(result, &h.scheme)
+	for i := range h.labels {
+		result = append(result, &h.labels[i])
+	}
+	if h.port != nil {
+		result = append(result, h.port)
+	}
+	return result
+}
+
+// positionalVarsCount returns the number of positional vars in the host template
+func (h *hostTemplate) positionalVarsCount() int {
+	result := 0
+	for _, pt := range h.parts() {
+		for _, vp := range pt.varParts() {
+			if vp.name == "" {
+				result++
+			}
+		}
+	}
+	return result
+}
+
+// match matches the URL scheme, host and port against the host template
+//
+// host parts are matched with a path position of -1 (for any FixedMatchOption or VarMatchOption) - if the
+// host template has no port, the URL port is not checked
+func (h *hostTemplate) match(u *url.URL, vars PathVars, fOpts fixedMatchOptions, vOpts varMatchOptions) bool {
+	if u == nil || u.Host == "" || !h.scheme.match(strings.ToLower(u.Scheme), -1, vars, fOpts, vOpts) {
+		return false
+	}
+	labels := strings.Split(strings.ToLower(u.Hostname()), ".")
+	if len(labels) != len(h.labels) {
+		return false
+	}
+	for i := range h.labels {
+		if !h.labels[i].match(labels[i], -1, vars, fOpts, vOpts) {
+			return false
+		}
+	}
+	if h.port != nil {
+		port := u.Port()
+		return port != "" && h.port.match(port, -1, vars, fOpts, vOpts)
+	}
+	return true
+}
+
+// hostFrom generates the scheme, host and port (e.g. `https://www.example.com`) from the host template
+func (h *hostTemplate) hostFrom(tracker *positionsTracker) (string, error) {
+	var hb strings.Builder
+	tracker.pathPosition = -1
+	for i, pt := range h.parts() {
+		str, err := pt.pathFrom(tracker)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case i == 1:
+			hb.WriteString("://")
+		case h.port != nil && pt == h.port:
+			hb.WriteString(":")
+		case i > 1:
+			hb.WriteString(".")
+		}
+		hb.WriteString(str[1:])
+	}
+	tracker.pathPosition = 0
+	return hb.String(), nil
+}
+
+// buildNoPattern writes the host template (with any var patterns removed)
+func (h *hostTemplate) buildNoPattern(builder *strings.Builder) {
+	h.scheme.buildNoPattern(builder)
+	builder.WriteString("://")
+	for i, pt := range h.labels {
+		if i > 0 {
+			builder.WriteString(".")
+		}
+		pt.buildNoPattern(builder)
+	}
+	if h.port != nil {
+		builder.WriteString(":")
+		h.port.buildNoPattern(builder)
+	}
+}
+
+// signature returns a string that identifies the host template by what it matches (i.e. disregarding var names)
+func (h *hostTemplate) signature() string {
+	if h == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, pt := range h.parts() {
+		sb.WriteString(pt.signature() + "|")
+	}
+	return sb.String()
+}
+
+// requestUrl returns the URL of the request - with the host (and scheme) filled in from the request
+// where not present in the request URL (as is usual for inbound server requests)
+func requestUrl(req *http.Request) *url.URL {
+	u := *req.URL
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return &u
+}
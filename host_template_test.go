@@ -0,0 +1,231 @@
+package urit
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestTemplate_Host_Parse(t *testing.T) {
+	testCases := []struct {
+		template     string
+		expectLabels int
+		expectPort   bool
+		expectNoPats string
+		expectVars   []string
+	}{
+		{`https://{tenant}.api.example.com/v1/{id}`, 4, false, `https://{tenant}.api.example.com/v1/{id}`, []string{"tenant", "id"}},
+		{`https://{tenant:[a-z]+}.API.example.com/v1/{id}`, 4, false, `https://{tenant}.api.example.com/v1/{id}`, []string{"tenant", "id"}},
+		{`http://localhost:{port:[0-9]+}/health`, 1, true, `http://localhost:{port}/health`, []string{"port"}},
+		{`{scheme}://api-{region}.example.com`, 3, false, `{scheme}://api-{region}.example.com`, []string{"scheme", "region"}},
+		{`https://example.com{?q}`, 2, false, `https://example.com{?q}`, []string{"q"}},
+		{`https://?.example.com/?`, 3, false, `https://{}.example.com/{}`, []string{"", ""}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmp, err := NewTemplate(tc.template)
+			require.NoError(t, err)
+			rt := tmp.(*template)
+			require.NotNil(t, rt.host)
+			require.Equal(t, tc.expectLabels, len(rt.host.labels))
+			require.Equal(t, tc.expectPort, rt.host.port != nil)
+			require.Equal(t, tc.template, tmp.OriginalTemplate())
+			require.Equal(t, tc.expectNoPats, tmp.Template(true))
+			names := make([]string, 0)
+			for _, v := range tmp.Vars() {
+				names = append(names, v.Name)
+			}
+			require.Equal(t, tc.expectVars, names)
+		})
+	}
+
+	tmp, err := NewTemplate(`/users/{id}`)
+	require.NoError(t, err)
+	require.Nil(t, tmp.(*template).host)
+	tmp, err = NewTemplate(`users/{id:[a-z]://}`)
+	require.NoError(t, err)
+	require.Nil(t, tmp.(*template).host)
+}
+
+func TestTemplate_Host_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template  string
+		expectErr string
+	}{
+		{`://example.com/users`, `template scheme cannot be empty`},
+		{`https:///users`, `template host cannot be empty`},
+		{`https://example..com/users`, `host parts cannot be empty`},
+		{`https://*.example.com/users`, `catch-all var cannot be used in template host`},
+		{`https://example.com:/users`, `host parts cannot be empty`},
+		{`https://{tenant:[}.example.com`, `path var regexp problem`},
+		{`https://{id}.example.com/?`, `template cannot contain both positional and named path variables`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewTemplate(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestTemplate_Host_Matches(t *testing.T) {
+	testCases := []struct {
+		template   string
+		path       string
+		expectOk   bool
+		expectVars map[string]string
+	}{
+		{`https://{tenant}.api.example.com/v1/{id}`, `https://acme.api.example.com/v1/123`, true, map[string]string{"tenant": "acme", "id": "123"}},
+		{`https://{tenant}.api.example.com/v1/{id}`, `https://ACME.Api.Example.com/v1/123`, true, map[string]string{"tenant": "acme", "id": "123"}},
+		{`https://{tenant}.api.example.com/v1/{id}`, `HTTPS://acme.api.example.com:8443/v1/123`, true, map[string]string{"tenant": "acme", "id": "123"}},
+		{`https://{tenant}.api.example.com/v1/{id}`, `http://acme.api.example.com/v1/123`, false, nil},
+		{`https://{tenant}.api.example.com/v1/{id}`, `https://a.b.api.example.com/v1/123`, false, nil},
+		{`https://{tenant}.api.example.com/v1/{id}`, `https://acme.other.example.com/v1/123`, false, nil},
+		{`https://{tenant}.api.example.com/v1/{id}`, `/v1/123`, false, nil},
+		{`https://{tenant:[a-z]+}.api.example.com/v1/{id}`, `https://acme1.api.example.com/v1/123`, false, nil},
+		{`{scheme}://localhost:{port}/health`, `http://localhost:8080/health`, true, map[string]string{"scheme": "http", "port": "8080"}},
+		{`{scheme}://localhost:{port}/health`, `http://localhost/health`, false, nil},
+		{`https://api-{region}.example.com`, `https://api-eu.example.com`, true, map[string]string{"region": "eu"}},
+		{`https://example.com/search{?q}`, `https://example.com/search?q=go`, true, map[string]string{"q": "go"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				require.Equal(t, len(tc.expectVars), vars.Len())
+				for k, v := range tc.expectVars {
+					av, ok := vars.Get(k)
+					require.True(t, ok)
+					require.Equal(t, v, av)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplate_Host_MatchesRequest(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{tenant}.api.example.com/v1/{id}`)
+	req, err := http.NewRequest(http.MethodGet, `/v1/123`, nil)
+	require.NoError(t, err)
+	req.Host = "acme.api.example.com"
+	_, ok := tmp.MatchesRequest(req)
+	require.False(t, ok)
+	req.TLS = &tls.ConnectionState{}
+	vars, ok := tmp.MatchesRequest(req)
+	require.True(t, ok)
+	require.Equal(t, []PathVar{
+		{Name: "tenant", Position: 0, Value: "acme"},
+		{Name: "id", Position: 1, Value: "123"},
+	}, vars.GetAll())
+
+	u, err := url.Parse(`https://acme.api.example.com/v1/123`)
+	require.NoError(t, err)
+	_, ok = tmp.MatchesUrl(*u)
+	require.True(t, ok)
+
+	tmp = MustCreateTemplate(`/v1/{id}`)
+	_, ok = tmp.MatchesRequest(req)
+	require.True(t, ok)
+}
+
+func TestTemplate_Host_PathFrom(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{tenant}.api.example.com/v1/{id}{?fields}`)
+	pth, err := tmp.PathFrom(Named("tenant", "acme", "id", 123, "fields", "name"))
+	require.NoError(t, err)
+	require.Equal(t, `https://acme.api.example.com/v1/123?fields=name`, pth)
+	_, err = tmp.PathFrom(Named("id", 123))
+	require.Error(t, err)
+	require.Equal(t, `no var for 'tenant'`, err.Error())
+	pth, err = tmp.PathFrom(Named("id", 123), NewHost("http://localhost:8080"))
+	require.NoError(t, err)
+	require.Equal(t, `http://localhost:8080/v1/123`, pth)
+
+	tmp = MustCreateTemplate(`{scheme}://localhost:{port}`)
+	pth, err = tmp.PathFrom(Named("scheme", "http", "port", 8080))
+	require.NoError(t, err)
+	require.Equal(t, `http://localhost:8080`, pth)
+
+	tmp = MustCreateTemplate(`https://?.example.com/?[/?]`)
+	pth, err = tmp.PathFrom(Positional("www", 1, 2))
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/1/2`, pth)
+	// with a host option, positional vars are only used for the path...
+	pth, err = tmp.PathFrom(Positional(1), NewHost("https://example.org"))
+	require.NoError(t, err)
+	require.Equal(t, `https://example.org/1`, pth)
+	pth, err = tmp.PathFrom(Positional(1, 2), NewHost("https://example.org"))
+	require.NoError(t, err)
+	require.Equal(t, `https://example.org/1/2`, pth)
+	tmp = MustCreateTemplate(`https://?.example.com/?`)
+	pth, err = tmp.PathFrom(Positional("b"), NewHost("https://example.org"))
+	require.NoError(t, err)
+	require.Equal(t, `https://example.org/b`, pth)
+	req, err := tmp.RequestFrom(http.MethodGet, Positional("b"), nil, NewHost("https://example.org"))
+	require.NoError(t, err)
+	require.Equal(t, `https://example.org/b`, req.URL.String())
+
+	// round trip...
+	tmp = MustCreateTemplate(`https://{tenant}.api.example.com/v1/{id}`)
+	vars, ok := tmp.Matches(`https://acme.api.example.com/v1/123`)
+	require.True(t, ok)
+	pth, err = tmp.PathFrom(vars)
+	require.NoError(t, err)
+	require.Equal(t, `https://acme.api.example.com/v1/123`, pth)
+	req, err = tmp.RequestFrom(http.MethodGet, vars, nil)
+	require.NoError(t, err)
+	require.Equal(t, `acme.api.example.com`, req.Host)
+}
+
+func TestTemplate_Host_SubAndResolveTo(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{tenant}.api.example.com:{port}/v1/`)
+	tmp2, err := tmp.Sub(`/users/{id}`)
+	require.NoError(t, err)
+	require.Equal(t, `https://{tenant}.api.example.com:{port}/v1/users/{id}`, tmp2.OriginalTemplate())
+	_, ok := tmp2.Matches(`https://acme.api.example.com:443/v1/users/1`)
+	require.True(t, ok)
+	_, err = tmp.Sub(`https://example.com/users`)
+	require.Error(t, err)
+	require.Equal(t, `sub-path cannot contain host`, err.Error())
+
+	tmp3, err := tmp2.ResolveTo(Named("tenant", "acme", "id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `https://acme.api.example.com:{port}/v1/users/1`, tmp3.OriginalTemplate())
+	vars, ok := tmp3.Matches(`https://acme.api.example.com:443/v1/users/1`)
+	require.True(t, ok)
+	require.Equal(t, 1, vars.Len())
+	_, ok = tmp3.Matches(`https://other.api.example.com:443/v1/users/1`)
+	require.False(t, ok)
+}
+
+func TestRouter_Host(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/v1/{id}`), "any"))
+	require.NoError(t, r.Add(MustCreateTemplate(`https://{tenant}.api.example.com/v1/{id}`), "tenant"))
+	require.NoError(t, r.Add(MustCreateTemplate(`https://www.example.com/v1/{id}`), "www"))
+	err := r.Add(MustCreateTemplate(`https://{other}.api.example.com/v1/{x}`), nil)
+	require.Error(t, err)
+	require.Equal(t, `template 'https://{other}.api.example.com/v1/{x}' is shadowed by template 'https://{tenant}.api.example.com/v1/{id}'`, err.Error())
+
+	m, ok := r.Match(`https://acme.api.example.com/v1/1`)
+	require.True(t, ok)
+	require.Equal(t, "tenant", m.Payload)
+	m, ok = r.Match(`https://www.example.com/v1/1`)
+	require.True(t, ok)
+	require.Equal(t, "www", m.Payload)
+	m, ok = r.Match(`https://other.com/v1/1`)
+	require.True(t, ok)
+	require.Equal(t, "any", m.Payload)
+
+	req, err := http.NewRequest(http.MethodGet, `/v1/1`, nil)
+	require.NoError(t, err)
+	req.Host = "acme.api.example.com"
+	req.TLS = &tls.ConnectionState{}
+	m, ok = r.MatchRequest(req)
+	require.True(t, ok)
+	require.Equal(t, "tenant", m.Payload)
+}
@@ -0,0 +1,263 @@
+package urit
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MismatchReason is the reason a path did not match a template (see MatchResult)
+type MismatchReason int
+
+const (
+	MismatchNone          MismatchReason = iota // the path matched
+	MismatchPath                                // the path could not be parsed or decoded (or does not match the template - for templates that cannot give detailed diagnostics)
+	MismatchSegmentCount                        // the number of path segments is not allowed by the template
+	MismatchFixed                               // a path segment did not match the fixed path part
+	MismatchRegexp                              // a path segment did not match the var regexp
+	MismatchVarType                             // a path segment could not be converted by the var type (e.g. `{d:date}`)
+	MismatchOption                              // a path segment was rejected by a FixedMatchOption or VarMatchOption
+	MismatchHost                                // the URL scheme, host or port did not match the template host
+	MismatchQuery                               // a query param did not match the template query section
+	MismatchTrailingSlash                       // the path trailing slash did not match the template (see StrictTrailingSlash)
+	MismatchRedirect                            // the path only matches in its canonical form (see SuggestRedirect and MatchResult.Redirect)
+)
+
+// MatchResult is the detailed result of matching a path against a template (see Template.MatchDetailed)
+type MatchResult struct {
+	// Matched is whether the path matched the template
+	Matched bool
+	// Vars is the extracted path vars (if the path matched)
+	Vars PathVars
+	// Reason is the reason the path did not match
+	Reason MismatchReason
+	// SegmentIndex is the index of the path segment that did not match (-1 if the mismatch is not for a specific path segment)
+	SegmentIndex int
+	// ExpectedSegments is the number of template path parts (-1 if not known - e.g. for RFC 6570 templates)
+	ExpectedSegments int
+	// ActualSegments is the number of path segments (-1 if the path could not be parsed)
+	ActualSegments int
+	// Expected is the expected fixed value or regexp (or query param, host etc.)
+	Expected string
+	// Actual is the actual path segment (or query param value, host etc.)
+	Actual string
+	// Option is the FixedMatchOption or VarMatchOption that rejected the path segment (for MismatchOption)
+	Option interface{}
+	// Redirect is the canonical path (including any host and query) that matches the template (for MismatchRedirect)
+	Redirect string
+}
+
+// String returns a description of the match result (e.g. for debugging or test failure messages)
+func (r MatchResult) String() string {
+	switch r.Reason {
+	case MismatchNone:
+		return "matched"
+	case MismatchSegmentCount:
+		return fmt.Sprintf("path has %d segments, template has %d", r.ActualSegments, r.ExpectedSegments)
+	case MismatchFixed:
+		return fmt.Sprintf("segment %d: expected '%s', got '%s'", r.SegmentIndex, r.Expected, r.Actual)
+	case MismatchRegexp:
+		return fmt.Sprintf("segment %d: '%s' does not match regexp '%s'", r.SegmentIndex, r.Actual, r.Expected)
+	case MismatchVarType:
+		return fmt.Sprintf("segment %d: '%s' is not a valid '%s'", r.SegmentIndex, r.Actual, r.Expected)
+	case MismatchOption:
+		return fmt.Sprintf("segment %d: '%s' rejected by option %T", r.SegmentIndex, r.Actual, r.Option)
+	case MismatchHost:
+		return fmt.Sprintf("host '%s' does not match '%s'", r.Actual, r.Expected)
+	case MismatchQuery:
+		if r.Expected == "" {
+			return fmt.Sprintf("invalid query '%s'", r.Actual)
+		}
+		return fmt.Sprintf("query param '%s' value '%s' does not match", r.Expected, r.Actual)
+	case MismatchTrailingSlash:
+		return fmt.Sprintf("path '%s' trailing slash does not match template '%s'", r.Actual, r.Expected)
+	case MismatchRedirect:
+		return fmt.Sprintf("path '%s' should be redirected to '%s'", r.Actual, r.Redirect)
+	}
+	if r.Expected != "" {
+		return fmt.Sprintf("path '%s' does not match template '%s'", r.Actual, r.Expected)
+	}
+	return fmt.Sprintf("invalid path '%s'", r.Actual)
+}
+
+// mismatch records the mismatch details (if the result is being recorded)
+func (r *MatchResult) mismatch(reason MismatchReason, segmentIndex int, expected string, actual string, option interface{}) {
+	if r != nil {
+		r.Reason = reason
+		r.SegmentIndex = segmentIndex
+		r.Expected = expected
+		r.Actual = actual
+		r.Option = option
+	}
+}
+
+// explainMismatch records why the path segment did not match the path part
+func (r *MatchResult) explainMismatch(pt *pathPart, segmentIndex int, s string, vars PathVars, fOpts fixedMatchOptions, vOpts varMatchOptions) {
+	if r == nil {
+		return
+	} else if pt.fixed {
+		if len(fOpts) > 0 && (pt.fixedValue == s || pt.decodedFixedValue() == s) {
+			r.mismatch(MismatchOption, segmentIndex, pt.fixedValue, s, fOpts[0])
+		} else {
+			r.mismatch(MismatchFixed, segmentIndex, pt.fixedValue, s, nil)
+		}
+		return
+	}
+	parts := []*pathPart{pt}
+	values := []string{s}
+	if len(pt.subParts) > 0 {
+		orx, idxs := pt.overallRegexpAndIdxs()
+		sms := orx.FindStringSubmatch(s)
+		if len(sms) == 0 {
+			r.mismatch(MismatchRegexp, segmentIndex, orx.String(), s, nil)
+			return
+		}
+		parts, values = make([]*pathPart, 0), make([]string, 0)
+		for i := range pt.subParts {
+			if !pt.subParts[i].fixed {
+				parts = append(parts, &pt.subParts[i])
+				values = append(values, sms[idxs[i]])
+			}
+		}
+	}
+	for i, vp := range parts {
+		v := values[i]
+		if o := vOpts.rejecting(v, vars.Len(), vp.name, vp, segmentIndex, vars); o != nil {
+			r.mismatch(MismatchOption, segmentIndex, vp.orgRegexp, v, o)
+			return
+		} else if vp.regexp != nil && !vp.regexp.MatchString(v) && !vOpts.applicable(v, vars.Len(), vp.name, vp, segmentIndex, vars) {
+			r.mismatch(MismatchRegexp, segmentIndex, vp.regexpSource(), v, nil)
+			return
+		} else if _, ok := vp.convertTyped(v); !ok {
+			r.mismatch(MismatchVarType, segmentIndex, vp.orgRegexp, v, nil)
+			return
+		}
+	}
+	r.mismatch(MismatchPath, segmentIndex, "", s, nil)
+}
+
+// rejecting returns the first applicable option that rejects the value (nil if any applicable option accepts the value)
+func (opts varMatchOptions) rejecting(value string, position int, name string, pt *pathPart, pathPos int, vars PathVars) interface{} {
+	var result interface{}
+	for _, o := range opts {
+		if o.Applicable(value, position, name, pt.regexp, pt.orgRegexp, pathPos, vars) {
+			if _, ok := o.Match(value, position, name, pt.regexp, pt.orgRegexp, pathPos, vars); ok {
+				return nil
+			} else if result == nil {
+				result = o
+			}
+		}
+	}
+	return result
+}
+
+func (opts varMatchOptions) applicable(value string, position int, name string, pt *pathPart, pathPos int, vars PathVars) bool {
+	for _, o := range opts {
+		if o.Applicable(value, position, name, pt.regexp, pt.orgRegexp, pathPos, vars) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchDetailed checks whether the specified path matches the template - returning a MatchResult with
+// the extracted path vars or, if not a successful match, the reason it did not match
+func (t *template) MatchDetailed(path string, options ...interface{}) MatchResult {
+	result := MatchResult{
+		SegmentIndex:     -1,
+		ExpectedSegments: len(t.pathParts),
+		ActualSegments:   -1,
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		result.mismatch(MismatchPath, -1, "", path, nil)
+		return result
+	}
+	policy := t.slashPolicyFor(options)
+	if !t.matchDetailed(path, u, options, policy, &result) && policy&SuggestRedirect != 0 {
+		escaped, _ := t.matchPath(u, options)
+		if canonical := t.canonicalPath(escaped, policy); canonical != escaped {
+			if redirect, ok := t.redirectFor(u, canonical, options); ok {
+				result.mismatch(MismatchRedirect, -1, canonical, escaped, nil)
+				result.Redirect = redirect
+			}
+		}
+	}
+	return result
+}
+
+// matchDetailed matches the URL against the template - recording any mismatch in the result
+func (t *template) matchDetailed(path string, u *url.URL, options []interface{}, policy SlashPolicy, result *MatchResult) bool {
+	escaped, ok := t.matchPath(u, options)
+	if !ok {
+		result.mismatch(MismatchPath, -1, "", path, nil)
+		return false
+	} else if !t.trailingSlashMatches(escaped, policy) {
+		result.mismatch(MismatchTrailingSlash, -1, t.originalTemplate, escaped, nil)
+		return false
+	}
+	pts, ok := splitPathPolicy(escaped, policy)
+	if !ok {
+		result.mismatch(MismatchPath, -1, "", path, nil)
+		return false
+	}
+	result.ActualSegments = len(pts)
+	if pts, ok = decodeSegments(pts, options); ok {
+		if vars, ok := t.matchSegmentsDetail(u, pts, options, result); ok {
+			result.Matched = true
+			result.Vars = vars
+			return true
+		}
+	} else {
+		result.mismatch(MismatchPath, -1, "", path, nil)
+	}
+	return false
+}
+
+// MatchDetailed checks whether the specified path matches the template - returning a MatchResult with
+// the extracted path vars or, if not a successful match, the reason it did not match
+//
+// RFC 6570 templates are not matched segment by segment - so a mismatch is always reported as MismatchPath
+func (t *rfc6570Template) MatchDetailed(path string, options ...interface{}) MatchResult {
+	result := MatchResult{
+		SegmentIndex:     -1,
+		ExpectedSegments: -1,
+		ActualSegments:   -1,
+	}
+	if vars, ok := t.Matches(path, options...); ok {
+		result.Matched = true
+		result.Vars = vars
+	} else {
+		result.mismatch(MismatchPath, -1, t.OriginalTemplate(), path, nil)
+	}
+	return result
+}
+
+// explainHostMismatch records the host mismatch
+func (r *MatchResult) explainHostMismatch(h *hostTemplate, u *url.URL) {
+	if r != nil {
+		var hb strings.Builder
+		h.buildNoPattern(&hb)
+		actual := ""
+		if u != nil && u.Host != "" {
+			actual = strings.ToLower(u.Scheme + "://" + u.Host)
+		}
+		r.mismatch(MismatchHost, -1, hb.String(), actual, nil)
+	}
+}
+
+// explainCatchAllMismatch records why the remaining path segments did not match the catch-all path part
+func (r *MatchResult) explainCatchAllMismatch(pt *pathPart, pathPos int, pts []string) {
+	if r == nil {
+		return
+	} else if pt.regexp != nil {
+		for i, s := range pts {
+			if !pt.regexp.MatchString(s) {
+				r.mismatch(MismatchRegexp, pathPos+i, pt.regexpSource(), s, nil)
+				return
+			}
+		}
+	}
+	// otherwise, must have been rejected by a VarMatchOption...
+	r.mismatch(MismatchOption, pathPos, pt.orgRegexp, Segments(pts).String(), nil)
+}
@@ -0,0 +1,192 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatchDetailed(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id:[0-9]+}/{n:int}/x-{a:[a-z]+}-{b}{?q!:[0-9]+}`)
+
+	r := tmp.MatchDetailed(`/foo/123/-4/x-abc-def?q=1`)
+	require.True(t, r.Matched)
+	require.Equal(t, MismatchNone, r.Reason)
+	require.Equal(t, "matched", r.String())
+	require.Equal(t, 4, r.ExpectedSegments)
+	require.Equal(t, 4, r.ActualSegments)
+	v, ok := r.Vars.GetNamedFirst("a")
+	require.True(t, ok)
+	require.Equal(t, "abc", v)
+
+	testCases := []struct {
+		path         string
+		expectReason MismatchReason
+		expectIndex  int
+		expectString string
+	}{
+		{
+			path:         `/foo/123`,
+			expectReason: MismatchSegmentCount,
+			expectIndex:  -1,
+			expectString: "path has 2 segments, template has 4",
+		},
+		{
+			path:         `/bar/123/4/x-abc-def?q=1`,
+			expectReason: MismatchFixed,
+			expectIndex:  0,
+			expectString: "segment 0: expected 'foo', got 'bar'",
+		},
+		{
+			path:         `/foo/abc/4/x-abc-def?q=1`,
+			expectReason: MismatchRegexp,
+			expectIndex:  1,
+			expectString: "segment 1: 'abc' does not match regexp '[0-9]+'",
+		},
+		{
+			path:         `/foo/123/99999999999999999999/x-abc-def?q=1`,
+			expectReason: MismatchVarType,
+			expectIndex:  2,
+			expectString: "segment 2: '99999999999999999999' is not a valid 'int'",
+		},
+		{
+			path:         `/foo/123/4/y-abc-def?q=1`,
+			expectReason: MismatchRegexp,
+			expectIndex:  3,
+		},
+		{
+			path:         `/foo/123/4/x-abc-def?q=x`,
+			expectReason: MismatchQuery,
+			expectIndex:  -1,
+			expectString: "query param 'q' value 'x' does not match",
+		},
+		{
+			path:         `/foo/123/4/x-abc-def`,
+			expectReason: MismatchQuery,
+			expectIndex:  -1,
+			expectString: "query param 'q' value '' does not match",
+		},
+		{
+			path:         `/foo/123/4/x-abc-def?q=%zz`,
+			expectReason: MismatchQuery,
+			expectIndex:  -1,
+			expectString: "invalid query 'q=%zz'",
+		},
+		{
+			path:         "/foo/\x7f",
+			expectReason: MismatchPath,
+			expectIndex:  -1,
+			expectString: "invalid path '/foo/\x7f'",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			r := tmp.MatchDetailed(tc.path)
+			require.False(t, r.Matched, "test case %d", i)
+			require.Nil(t, r.Vars)
+			require.Equal(t, tc.expectReason, r.Reason)
+			require.Equal(t, tc.expectIndex, r.SegmentIndex)
+			if tc.expectString != "" {
+				require.Equal(t, tc.expectString, r.String())
+			}
+			_, ok := tmp.Matches(tc.path)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestMatchDetailed_Options(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id}`)
+
+	r := tmp.MatchDetailed(`/FOO/123`, CaseInsensitiveFixed)
+	require.True(t, r.Matched)
+
+	r = tmp.MatchDetailed(`/foo/123`, &rejectFixed{})
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchOption, r.Reason)
+	require.Equal(t, 0, r.SegmentIndex)
+	require.Equal(t, "foo", r.Expected)
+	require.Equal(t, "foo", r.Actual)
+	require.IsType(t, &rejectFixed{}, r.Option)
+	require.Equal(t, "segment 0: 'foo' rejected by option *urit.rejectFixed", r.String())
+
+	r = tmp.MatchDetailed(`/foo/123`, &rejectVar{})
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchOption, r.Reason)
+	require.Equal(t, 1, r.SegmentIndex)
+	require.Equal(t, "123", r.Actual)
+	require.IsType(t, &rejectVar{}, r.Option)
+
+	r = tmp.MatchDetailed(`/foo/abc`, &rejectVar{})
+	require.True(t, r.Matched)
+}
+
+func TestMatchDetailed_Host(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{sub}.example.com/foo`)
+
+	r := tmp.MatchDetailed(`https://api.example.com/foo`)
+	require.True(t, r.Matched)
+
+	r = tmp.MatchDetailed(`http://api.example.com/foo`)
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchHost, r.Reason)
+	require.Equal(t, "https://{sub}.example.com", r.Expected)
+	require.Equal(t, "http://api.example.com", r.Actual)
+	require.Equal(t, "host 'http://api.example.com' does not match 'https://{sub}.example.com'", r.String())
+
+	r = tmp.MatchDetailed(`/foo`)
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchHost, r.Reason)
+	require.Equal(t, "", r.Actual)
+}
+
+func TestMatchDetailed_CatchAll(t *testing.T) {
+	tmp := MustCreateTemplate(`/files/{path...:[a-z]+}`)
+
+	r := tmp.MatchDetailed(`/files/a/b/c`)
+	require.True(t, r.Matched)
+
+	r = tmp.MatchDetailed(`/files/a/b/1`)
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchRegexp, r.Reason)
+	require.Equal(t, 3, r.SegmentIndex)
+	require.Equal(t, "1", r.Actual)
+
+	r = tmp.MatchDetailed(`/files/a/b`, &rejectVar{})
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchOption, r.Reason)
+	require.Equal(t, 1, r.SegmentIndex)
+	require.Equal(t, "a/b", r.Actual)
+}
+
+func TestMatchDetailed_Rfc6570(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/foo{/id}`)
+
+	r := tmp.MatchDetailed(`/foo/123`)
+	require.True(t, r.Matched)
+	require.NotNil(t, r.Vars)
+
+	r = tmp.MatchDetailed(`/bar/123`)
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchPath, r.Reason)
+	require.Equal(t, -1, r.ExpectedSegments)
+	require.True(t, strings.HasPrefix(r.String(), "path '/bar/123' does not match template"))
+}
+
+type rejectFixed struct{}
+
+func (o *rejectFixed) Match(value string, expected string, pathPos int, vars PathVars) bool {
+	return false
+}
+
+// rejectVar rejects var values that are all digits
+type rejectVar struct{}
+
+func (o *rejectVar) Applicable(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) bool {
+	return true
+}
+
+func (o *rejectVar) Match(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) (string, bool) {
+	return value, strings.Trim(value, "0123456789") != "" && !strings.Contains(value, "/")
+}
@@ -0,0 +1,208 @@
+package urit
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// EncodedSlashPolicy is the policy for how encoded slashes (`%2F`) in paths are treated when paths are normalized
+// (see Normalize)
+//
+// An EncodedSlashPolicy is a NormalizeOption - so can be used as an option with NewTemplate (so that the template always
+// normalizes paths before matching) or with any of the match methods
+type EncodedSlashPolicy int
+
+const (
+	KeepEncodedSlashes   EncodedSlashPolicy = iota // encoded slashes are kept (as `%2F`) - so are part of the path segment (the default)
+	DecodeEncodedSlashes                           // encoded slashes are decoded - so separate path segments (any empty segments this creates are collapsed - e.g. `/a/%2F/b` is normalized to `/a/b`)
+	RejectEncodedSlashes                           // paths with encoded slashes are rejected (Normalize returns an error and the path does not match)
+)
+
+// NormalizeOption is the option interface for normalizing paths before matching (see Normalize)
+type NormalizeOption interface {
+	GetEncodedSlashPolicy() EncodedSlashPolicy
+}
+
+// GetEncodedSlashPolicy returns the encoded slash policy (so that an EncodedSlashPolicy can be used as a NormalizeOption)
+func (p EncodedSlashPolicy) GetEncodedSlashPolicy() EncodedSlashPolicy {
+	return p
+}
+
+// Normalized is a NormalizeOption that can be used with NewTemplate or any of the match methods to normalize paths
+// before matching (see Normalize)
+var Normalized NormalizeOption = KeepEncodedSlashes
+
+// Normalize normalizes the path (RFC 3986 section 6) - percent-encodings are upper-cased, percent-encoded unreserved
+// characters are decoded and dot segments (`.` and `..`) are removed. Encoded slashes are treated according to any
+// NormalizeOption (EncodedSlashPolicy) - by default, they are kept
+//
+// any scheme and host are lower-cased (and any query or fragment is left unchanged)
+//
+// returns an error if the path has an invalid percent-encoding (or has an encoded slash and the RejectEncodedSlashes
+// policy is used)
+func Normalize(path string, options ...interface{}) (string, error) {
+	policy := KeepEncodedSlashes
+	if n := normalizeOption(nil, options); n != nil {
+		policy = n.GetEncodedSlashPolicy()
+	}
+	start, end := pathBounds(path)
+	pth, err := normalizePath(path[start:end], policy)
+	if err != nil {
+		return "", err
+	}
+	return normalizeAuthority(path[:start]) + pth + path[end:], nil
+}
+
+// normalizeOption returns the last NormalizeOption in the options (or the normalize option given, if none)
+func normalizeOption(normalize NormalizeOption, options []interface{}) NormalizeOption {
+	for _, o := range options {
+		if n, ok := o.(NormalizeOption); ok {
+			normalize = n
+		}
+	}
+	return normalize
+}
+
+// matchPath returns the (escaped) URL path to be matched - normalized if the template or options
+// specify a NormalizeOption
+func (t *template) matchPath(u *url.URL, options []interface{}) (string, bool) {
+	return normalizedPath(u.EscapedPath(), t.normalize, options)
+}
+
+func normalizedPath(escaped string, normalize NormalizeOption, options []interface{}) (string, bool) {
+	if n := normalizeOption(normalize, options); n != nil {
+		pth, err := normalizePath(escaped, n.GetEncodedSlashPolicy())
+		return pth, err == nil
+	}
+	return escaped, true
+}
+
+// pathBounds returns the start and end of the path (i.e. after any scheme and authority, and before any query or fragment)
+func pathBounds(s string) (int, int) {
+	end := len(s)
+	if i := strings.IndexAny(s, "?#"); i != -1 {
+		end = i
+	}
+	start := 0
+	if i := strings.Index(s[:end], "://"); i != -1 && !strings.Contains(s[:i], "/") {
+		start = i + 3
+	} else if strings.HasPrefix(s, "//") {
+		start = 2
+	} else {
+		return 0, end
+	}
+	if i := strings.IndexByte(s[start:end], '/'); i != -1 {
+		return start + i, end
+	}
+	return end, end
+}
+
+// normalizeAuthority lower-cases the scheme and host (but not any user info)
+func normalizeAuthority(s string) string {
+	if i := strings.LastIndexByte(s, '@'); i != -1 {
+		if j := strings.Index(s, "://"); j != -1 && j < i {
+			return strings.ToLower(s[:j]) + s[j:i] + strings.ToLower(s[i:])
+		}
+		return s[:i] + strings.ToLower(s[i:])
+	}
+	return strings.ToLower(s)
+}
+
+// normalizePath normalizes the percent-encodings and removes dot segments from the (escaped) path
+func normalizePath(s string, policy EncodedSlashPolicy) (string, error) {
+	if strings.IndexByte(s, '%') != -1 {
+		var sb strings.Builder
+		sb.Grow(len(s))
+		// whether the last slash was a decoded slash (so that empty segments it creates are collapsed)...
+		decodedSlash := false
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if c != '%' {
+				if c != '/' || !decodedSlash || !endsWithSlash(&sb) {
+					sb.WriteByte(c)
+				}
+				decodedSlash = false
+				continue
+			} else if i+2 >= len(s) || !isHexChar(s[i+1]) || !isHexChar(s[i+2]) {
+				return "", errors.New("invalid percent-encoding in path")
+			}
+			b := unhex(s[i+1])<<4 | unhex(s[i+2])
+			switch {
+			case isUnreservedChar(b):
+				sb.WriteByte(b)
+			case b == '/' && policy == DecodeEncodedSlashes:
+				if !endsWithSlash(&sb) {
+					sb.WriteByte('/')
+				}
+				decodedSlash = true
+				i += 2
+				continue
+			case b == '/' && policy == RejectEncodedSlashes:
+				return "", errors.New("path contains encoded slash")
+			default:
+				sb.WriteByte('%')
+				sb.WriteByte(upperHex[b>>4])
+				sb.WriteByte(upperHex[b&15])
+			}
+			i += 2
+		}
+		s = sb.String()
+	}
+	return removeDotSegments(s), nil
+}
+
+func endsWithSlash(sb *strings.Builder) bool {
+	return sb.Len() > 0 && sb.String()[sb.Len()-1] == '/'
+}
+
+// removeDotSegments removes `.` and `..` segments from the path (RFC 3986 section 5.2.4)
+func removeDotSegments(s string) string {
+	if !strings.HasPrefix(s, ".") && !strings.Contains(s, "/.") {
+		return s
+	}
+	in, out := s, ""
+	for in != "" {
+		switch {
+		case strings.HasPrefix(in, "../"):
+			in = in[3:]
+		case strings.HasPrefix(in, "./"), strings.HasPrefix(in, "/./"):
+			in = in[2:]
+		case in == "/.":
+			in = "/"
+		case strings.HasPrefix(in, "/../"):
+			in = in[3:]
+			out = out[:lastSlash(out)]
+		case in == "/..":
+			in = "/"
+			out = out[:lastSlash(out)]
+		case in == "." || in == "..":
+			in = ""
+		default:
+			seg := in
+			if i := strings.IndexByte(in[1:], '/'); i != -1 {
+				seg = in[:i+1]
+			}
+			out += seg
+			in = in[len(seg):]
+		}
+	}
+	return out
+}
+
+func lastSlash(s string) int {
+	if i := strings.LastIndexByte(s, '/'); i != -1 {
+		return i
+	}
+	return 0
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,164 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		path        string
+		options     []interface{}
+		expect      string
+		expectError string
+	}{
+		{`/foo/bar`, nil, `/foo/bar`, ""},
+		{`/foo/./bar`, nil, `/foo/bar`, ""},
+		{`/foo/../bar`, nil, `/bar`, ""},
+		{`/foo/bar/..`, nil, `/foo/`, ""},
+		{`/foo/bar/.`, nil, `/foo/bar/`, ""},
+		{`/../foo`, nil, `/foo`, ""},
+		{`/a/b/c/./../../g`, nil, `/a/g`, ""},
+		{`/foo/..bar/.baz`, nil, `/foo/..bar/.baz`, ""},
+		{`/foo%2e/%2E%2E/bar`, nil, `/bar`, ""},
+		{`/foo%7e%41%2d/bar`, nil, `/foo~A-/bar`, ""},
+		{`/foo%2fbar/%3a%c3%a9`, nil, `/foo%2Fbar/%3A%C3%A9`, ""},
+		{`/foo%2Fbar`, []interface{}{KeepEncodedSlashes}, `/foo%2Fbar`, ""},
+		{`/foo%2Fbar`, []interface{}{DecodeEncodedSlashes}, `/foo/bar`, ""},
+		{`/foo/%2E%2E%2Fbar`, []interface{}{DecodeEncodedSlashes}, `/bar`, ""},
+		{`/foo%2fbar`, []interface{}{RejectEncodedSlashes}, ``, "path contains encoded slash"},
+		{`/a/%2f/b`, []interface{}{DecodeEncodedSlashes}, `/a/b`, ""},
+		{`/a/%2F%2F/b`, []interface{}{DecodeEncodedSlashes}, `/a/b`, ""},
+		{`/a%2F/b`, []interface{}{DecodeEncodedSlashes}, `/a/b`, ""},
+		{`/a/%2Fb`, []interface{}{DecodeEncodedSlashes}, `/a/b`, ""},
+		{`%2Fa`, []interface{}{DecodeEncodedSlashes}, `/a`, ""},
+		{`/a/%2F`, []interface{}{DecodeEncodedSlashes}, `/a/`, ""},
+		{`/a//%2Fb`, []interface{}{DecodeEncodedSlashes}, `/a//b`, ""},
+		{`/foo%2`, nil, ``, "invalid percent-encoding in path"},
+		{`/foo%zz`, nil, ``, "invalid percent-encoding in path"},
+		{`/foo/../bar?q=/./%7e#/../x`, nil, `/bar?q=/./%7e#/../x`, ""},
+		{`HTTPS://User@Example.COM:8080/a/../b`, nil, `https://User@example.com:8080/b`, ""},
+		{`HTTP://Example.COM`, nil, `http://example.com`, ""},
+		{`//Example.COM/a/./b`, nil, `//example.com/a/b`, ""},
+		{`foo/./bar`, nil, `foo/bar`, ""},
+		{``, nil, ``, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			pth, err := Normalize(tc.path, tc.options...)
+			if tc.expectError != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectError, err.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expect, pth)
+			}
+		})
+	}
+}
+
+func TestTemplate_Matches_Normalized(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		options  []interface{}
+		expectOk bool
+		expectId string
+	}{
+		{`/foo/{id}`, `/foo/./bar/../123`, nil, false, ""},
+		{`/foo/{id}`, `/foo/./bar/../123`, []interface{}{Normalized}, true, "123"},
+		{`/foo/{id}`, `/bar/../foo/%7E1`, []interface{}{Normalized}, true, "~1"},
+		{`/foo~bar/{id}`, `/foo%7ebar/1`, []interface{}{Normalized}, true, "1"},
+		{`/foo/{id}`, `/foo/a%2fb`, []interface{}{Normalized}, true, "a/b"},
+		{`/foo/{id}`, `/foo/a%2fb`, []interface{}{DecodeEncodedSlashes}, false, ""},
+		{`/foo/{a}/{b}`, `/foo/a%2fb`, []interface{}{DecodeEncodedSlashes}, true, ""},
+		{`/foo/{id}`, `/foo/a%2fb`, []interface{}{RejectEncodedSlashes}, false, ""},
+		{`/foo/{a}/{b}`, `/foo/a/%2f/b`, []interface{}{DecodeEncodedSlashes}, true, ""},
+		{`/foo/{id}`, `/foo/%2f/b`, []interface{}{DecodeEncodedSlashes}, true, "b"},
+		{`/foo/{id}`, `/foo/%2f`, []interface{}{DecodeEncodedSlashes}, false, ""},
+		{`/foo/{id}`, `/foo/1/..`, []interface{}{Normalized}, false, ""},
+		{`/foo/{id}/`, `/foo/1/bar/..`, []interface{}{Normalized, StrictTrailingSlash}, true, "1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path, tc.options...)
+			require.Equal(t, tc.expectOk, ok)
+			if ok && tc.expectId != "" {
+				id, _ := vars.Get("id")
+				require.Equal(t, tc.expectId, id)
+			}
+			// same options used with NewTemplate...
+			tmp = MustCreateTemplate(tc.template, tc.options...)
+			_, ok = tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			require.Equal(t, tc.expectOk, tmp.MatchDetailed(tc.path).Matched)
+		})
+	}
+}
+
+func TestTemplate_Normalized_Derived(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id}`, DecodeEncodedSlashes)
+	require.Equal(t, []interface{}{DecodeEncodedSlashes}, tmp.Options())
+
+	st, err := tmp.Sub(`/{name}`)
+	require.NoError(t, err)
+	_, ok := st.Matches(`/foo/1%2Fbar`)
+	require.True(t, ok)
+	// match option overrides template option...
+	_, ok = st.Matches(`/foo/1%2Fbar`, KeepEncodedSlashes)
+	require.False(t, ok)
+
+	rt, err := tmp.ResolveTo(Named("id", 1))
+	require.NoError(t, err)
+	_, ok = rt.Matches(`/foo/x/../1`)
+	require.True(t, ok)
+
+	r := tmp.MatchDetailed(`/foo/%zz`)
+	require.Equal(t, MismatchPath, r.Reason)
+
+	vars, remainder, ok := tmp.MatchesPrefix(`/foo/1/./bar%2Fbaz`)
+	require.True(t, ok)
+	require.Equal(t, `/bar/baz`, remainder)
+	require.Equal(t, 1, vars.Len())
+}
+
+func TestRfc6570Template_Matches_Normalized(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}`)
+	_, ok := tmp.Matches(`/users/x/../123`)
+	require.False(t, ok)
+	vars, ok := tmp.Matches(`/users/x/../123`, Normalized)
+	require.True(t, ok)
+	id, _ := vars.Get("id")
+	require.Equal(t, "123", id)
+	_, ok = tmp.Matches(`/users/%zz`, Normalized)
+	require.False(t, ok)
+
+	vars, remainder, ok := tmp.MatchesPrefix(`/users/./123/x`, Normalized)
+	require.True(t, ok)
+	require.Equal(t, `/x`, remainder)
+	id, _ = vars.Get("id")
+	require.Equal(t, "123", id)
+}
+
+func TestRouter_Normalized(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/orders/{id}`), "order"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/files/{path...}`, DecodeEncodedSlashes), "files"))
+
+	_, ok := r.Match(`/orders/../orders/1`)
+	require.False(t, ok)
+	m, ok := r.Match(`/orders/../orders/1`, Normalized)
+	require.True(t, ok)
+	require.Equal(t, "order", m.Payload)
+	_, ok = r.Match(`/orders/%zz`, Normalized)
+	require.False(t, ok)
+
+	req, _ := http.NewRequest(http.MethodGet, `/files/a%2Fb/c`, nil)
+	m, ok = r.MatchRequest(req)
+	require.True(t, ok)
+	require.Equal(t, "files", m.Payload)
+	pth, _ := m.Vars.Get("path")
+	require.Equal(t, "a/b/c", pth)
+}
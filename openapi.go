@@ -0,0 +1,170 @@
+package urit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OpenApiPaths is the OpenAPI 3 paths object - path items keyed by OpenAPI path (e.g. `/orders/{id}`)
+type OpenApiPaths map[string]*OpenApiPathItem
+
+// OpenApiPathItem is an OpenAPI 3 path item (with just the parameters derived from the template)
+type OpenApiPathItem struct {
+	Parameters []OpenApiParameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// OpenApiParameter is an OpenAPI 3 parameter object (for path or query parameters)
+type OpenApiParameter struct {
+	Name        string         `json:"name" yaml:"name"`
+	In          string         `json:"in" yaml:"in"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Explode     *bool          `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Schema      *OpenApiSchema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// OpenApiSchema is an OpenAPI 3 schema object (for parameters)
+type OpenApiSchema struct {
+	Type    string         `json:"type" yaml:"type"`
+	Pattern string         `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Items   *OpenApiSchema `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+const (
+	openApiInPath  = "path"
+	openApiInQuery = "query"
+)
+
+// OpenApiPathsFrom converts templates into OpenAPI 3 paths
+//
+// Each template var becomes a path parameter (with any var regexp as the schema pattern) and each template query
+// param becomes a query parameter - positional vars are given generated names (`var1`, `var2`, etc.)
+//
+// Templates with optional groups (or a catch-all var) produce a path item for each possible path - and templates that
+// produce the same OpenAPI path have their parameters merged
+//
+// Any template host is not included (OpenAPI servers are not derived from templates)
+func OpenApiPathsFrom(templates ...Template) (OpenApiPaths, error) {
+	result := OpenApiPaths{}
+	for _, t := range templates {
+		rt, ok := t.(*template)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert template '%s' to OpenAPI", t.OriginalTemplate())
+		}
+		rt.addOpenApiPaths(result)
+	}
+	return result, nil
+}
+
+func (t *template) addOpenApiPaths(paths OpenApiPaths) {
+	var pb strings.Builder
+	params := make([]OpenApiParameter, 0)
+	queryParams := openApiQueryParams(t.queryParts)
+	seen := map[string]bool{}
+	posn := 0
+	emit := func() {
+		path := pb.String()
+		if path == "" {
+			path = "/"
+		}
+		item, ok := paths[path]
+		if !ok {
+			item = &OpenApiPathItem{Parameters: make([]OpenApiParameter, 0)}
+			paths[path] = item
+		}
+		item.addParameters(params)
+		item.addParameters(queryParams)
+	}
+	for i := range t.pathParts {
+		pt := &t.pathParts[i]
+		if t.isGroupBoundary(i) || pt.catchAll {
+			emit()
+		}
+		pb.WriteString("/")
+		if pt.fixed {
+			pb.WriteString(pt.fixedValue)
+			continue
+		}
+		parts := []*pathPart{pt}
+		if len(pt.subParts) > 0 {
+			parts = make([]*pathPart, 0, len(pt.subParts))
+			for j := range pt.subParts {
+				parts = append(parts, &pt.subParts[j])
+			}
+		}
+		for _, sp := range parts {
+			if sp.fixed {
+				pb.WriteString(sp.fixedValue)
+				continue
+			}
+			name := sp.name
+			if name == "" {
+				posn++
+				name = "var" + strconv.Itoa(posn)
+			} else if name == catchAllName {
+				name = "catchAll"
+			}
+			pb.WriteString("{" + name + "}")
+			if !seen[name] {
+				seen[name] = true
+				params = append(params, sp.openApiParameter(name))
+			}
+		}
+	}
+	emit()
+}
+
+func (pt *pathPart) openApiParameter(name string) OpenApiParameter {
+	result := OpenApiParameter{
+		Name:     name,
+		In:       openApiInPath,
+		Required: true,
+		Schema:   &OpenApiSchema{Type: "string"},
+	}
+	if pt.catchAll {
+		result.Description = "remaining path segments"
+	} else if pt.regexp != nil {
+		result.Schema.Pattern = pt.regexp.String()
+	}
+	return result
+}
+
+func openApiQueryParams(qps []queryPart) []OpenApiParameter {
+	result := make([]OpenApiParameter, 0, len(qps))
+	for _, qp := range qps {
+		schema := &OpenApiSchema{Type: "string"}
+		if qp.regexp != nil {
+			schema.Pattern = qp.regexp.String()
+		}
+		param := OpenApiParameter{
+			Name:     qp.name,
+			In:       openApiInQuery,
+			Required: qp.required,
+			Schema:   schema,
+		}
+		if qp.repeated {
+			explode := true
+			param.Explode = &explode
+			param.Schema = &OpenApiSchema{Type: "array", Items: schema}
+		}
+		result = append(result, param)
+	}
+	return result
+}
+
+// addParameters adds parameters to the path item (ignoring any already present)
+func (pi *OpenApiPathItem) addParameters(params []OpenApiParameter) {
+	for _, p := range params {
+		found := false
+		for _, ep := range pi.Parameters {
+			if ep.In == p.In && ep.Name == p.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pi.Parameters = append(pi.Parameters, p)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package urit
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OpenApiTemplate is a template (and method) for an operation imported from an OpenAPI 3 document
+type OpenApiTemplate struct {
+	Method      string
+	Path        string
+	OperationId string
+	Template    Template
+}
+
+// OpenApiTemplates is the templates imported from an OpenAPI 3 document (ordered by path and method)
+type OpenApiTemplates []OpenApiTemplate
+
+// MatchRequest finds the first template that matches the request (and request method) -
+// and if a successful match, returns the matched template and extracted path vars
+func (ts OpenApiTemplates) MatchRequest(req *http.Request, options ...interface{}) (OpenApiTemplate, PathVars, bool) {
+	for _, t := range ts {
+		if t.Method == req.Method {
+			if vars, ok := t.Template.MatchesRequest(req, options...); ok {
+				return t, vars, true
+			}
+		}
+	}
+	return OpenApiTemplate{}, nil, false
+}
+
+// TemplatesFromOpenApi creates templates from an OpenAPI 3 document (JSON or YAML) - a template for each path and method
+//
+// Path parameter schemas are converted to path var types (`int`, `float`, `bool`, `uuid` and `date` - for integer, number,
+// boolean, uuid and date schemas) or path var regexps (for enum and pattern schemas)
+// and query parameters are added as the template query section
+//
+// The options can be any FixedMatchOption or VarMatchOption (as used by NewTemplate)
+func TemplatesFromOpenApi(data []byte, options ...interface{}) (OpenApiTemplates, error) {
+	doc := &openApiDoc{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %s", err.Error())
+	}
+	paths := make([]string, 0, len(doc.Paths))
+	for pth := range doc.Paths {
+		paths = append(paths, pth)
+	}
+	sort.Strings(paths)
+	result := make(OpenApiTemplates, 0)
+	for _, pth := range paths {
+		item := doc.Paths[pth]
+		for _, mop := range item.operations() {
+			tmp, err := doc.template(pth, item.Parameters, mop.op.Parameters, options)
+			if err != nil {
+				return nil, fmt.Errorf("OpenAPI path '%s' (%s): %s", pth, mop.method, err.Error())
+			}
+			result = append(result, OpenApiTemplate{
+				Method:      mop.method,
+				Path:        pth,
+				OperationId: mop.op.OperationId,
+				Template:    tmp,
+			})
+		}
+	}
+	return result, nil
+}
+
+// TemplatesFromOpenApiFile is the same as TemplatesFromOpenApi, except that the document is read from the named file
+func TemplatesFromOpenApiFile(filename string, options ...interface{}) (OpenApiTemplates, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return TemplatesFromOpenApi(data, options...)
+}
+
+type openApiDoc struct {
+	Paths      map[string]*openApiPathItemDoc `yaml:"paths"`
+	Components struct {
+		Parameters map[string]*openApiParameterDoc `yaml:"parameters"`
+		Schemas    map[string]*openApiSchemaDoc    `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+type openApiPathItemDoc struct {
+	Parameters []*openApiParameterDoc `yaml:"parameters"`
+	Get        *openApiOperationDoc   `yaml:"get"`
+	Put        *openApiOperationDoc   `yaml:"put"`
+	Post       *openApiOperationDoc   `yaml:"post"`
+	Delete     *openApiOperationDoc   `yaml:"delete"`
+	Options    *openApiOperationDoc   `yaml:"options"`
+	Head       *openApiOperationDoc   `yaml:"head"`
+	Patch      *openApiOperationDoc   `yaml:"patch"`
+	Trace      *openApiOperationDoc   `yaml:"trace"`
+}
+
+type openApiOperationDoc struct {
+	OperationId string                 `yaml:"operationId"`
+	Parameters  []*openApiParameterDoc `yaml:"parameters"`
+}
+
+type openApiParameterDoc struct {
+	Ref      string            `yaml:"$ref"`
+	Name     string            `yaml:"name"`
+	In       string            `yaml:"in"`
+	Required bool              `yaml:"required"`
+	Schema   *openApiSchemaDoc `yaml:"schema"`
+}
+
+type openApiSchemaDoc struct {
+	Ref     string            `yaml:"$ref"`
+	Type    string            `yaml:"type"`
+	Format  string            `yaml:"format"`
+	Pattern string            `yaml:"pattern"`
+	Enum    []string          `yaml:"enum"`
+	Items   *openApiSchemaDoc `yaml:"items"`
+}
+
+type methodOperation struct {
+	method string
+	op     *openApiOperationDoc
+}
+
+func (pi *openApiPathItemDoc) operations() []methodOperation {
+	result := make([]methodOperation, 0)
+	for _, mop := range []methodOperation{
+		{http.MethodGet, pi.Get},
+		{http.MethodPut, pi.Put},
+		{http.MethodPost, pi.Post},
+		{http.MethodDelete, pi.Delete},
+		{http.MethodOptions, pi.Options},
+		{http.MethodHead, pi.Head},
+		{http.MethodPatch, pi.Patch},
+		{http.MethodTrace, pi.Trace},
+	} {
+		if mop.op != nil {
+			result = append(result, mop)
+		}
+	}
+	return result
+}
+
+// template creates the template for an OpenAPI path (and the path item and operation parameters)
+func (doc *openApiDoc) template(pth string, itemParams []*openApiParameterDoc, opParams []*openApiParameterDoc, options []interface{}) (Template, error) {
+	params := make([]*openApiParameterDoc, 0, len(itemParams)+len(opParams))
+	// operation parameters override path item parameters...
+	for _, p := range append(append(make([]*openApiParameterDoc, 0), itemParams...), opParams...) {
+		p, err := doc.resolveParameter(p)
+		if err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i, ep := range params {
+			if ep.Name == p.Name && ep.In == p.In {
+				params[i] = p
+				replaced = true
+			}
+		}
+		if !replaced {
+			params = append(params, p)
+		}
+	}
+	var tb strings.Builder
+	var qb strings.Builder
+	pathRxs := map[string]string{}
+	for _, p := range params {
+		rx, err := doc.schemaConstraint(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		switch p.In {
+		case openApiInPath:
+			pathRxs[p.Name] = rx
+		case openApiInQuery:
+			if qb.Len() > 0 {
+				qb.WriteString(",")
+			}
+			qb.WriteString(p.Name)
+			if p.Required {
+				qb.WriteString("!")
+			}
+			if p.Schema != nil && p.Schema.Type == "array" {
+				qb.WriteString("*")
+				if rx, err = doc.schemaConstraint(p.Schema.Items); err != nil {
+					return nil, err
+				}
+			}
+			if rx != "" {
+				qb.WriteString(":" + rx)
+			}
+		}
+	}
+	rest := pth
+	for {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start == -1 || end < start {
+			tb.WriteString(rest)
+			break
+		}
+		name := rest[start+1 : end]
+		tb.WriteString(rest[:start] + "{" + name)
+		if rx := pathRxs[name]; rx != "" {
+			tb.WriteString(":" + rx)
+		}
+		tb.WriteString("}")
+		rest = rest[end+1:]
+	}
+	if qb.Len() > 0 {
+		tb.WriteString("{?" + qb.String() + "}")
+	}
+	return NewTemplate(tb.String(), options...)
+}
+
+const openApiComponentsPrefix = "#/components/"
+
+func (doc *openApiDoc) resolveParameter(p *openApiParameterDoc) (*openApiParameterDoc, error) {
+	if p == nil {
+		return nil, fmt.Errorf("invalid parameter")
+	} else if p.Ref == "" {
+		return p, nil
+	} else if rp, ok := doc.Components.Parameters[strings.TrimPrefix(p.Ref, openApiComponentsPrefix+"parameters/")]; ok && rp.Ref == "" {
+		return rp, nil
+	}
+	return nil, fmt.Errorf("unresolved parameter ref '%s'", p.Ref)
+}
+
+// schemaConstraint converts a parameter schema to a path var constraint - either a var type name (see VarType) or a
+// regexp (empty if the schema does not constrain the value)
+func (doc *openApiDoc) schemaConstraint(s *openApiSchemaDoc) (string, error) {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		rs, ok := doc.Components.Schemas[strings.TrimPrefix(s.Ref, openApiComponentsPrefix+"schemas/")]
+		if !ok || depth > len(doc.Components.Schemas) {
+			return "", fmt.Errorf("unresolved schema ref '%s'", s.Ref)
+		}
+		s = rs
+	}
+	switch {
+	case s == nil:
+		return "", nil
+	case s.Pattern != "":
+		return stripRegexHeadAndTail(s.Pattern), nil
+	case len(s.Enum) > 0:
+		quoted := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			quoted = append(quoted, regexp.QuoteMeta(v))
+		}
+		return "(" + strings.Join(quoted, "|") + ")", nil
+	case s.Type == "integer":
+		return "int", nil
+	case s.Type == "number":
+		return "float", nil
+	case s.Type == "boolean":
+		return "bool", nil
+	case s.Format == "uuid":
+		return "uuid", nil
+	case s.Format == "date":
+		return "date", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,174 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testOpenApiYaml = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1"
+paths:
+  /orders/{id}:
+    parameters:
+      - $ref: '#/components/parameters/orderId'
+    get:
+      operationId: getOrder
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [name, total]
+        - name: X-Trace
+          in: header
+          schema:
+            type: string
+    delete:
+      operationId: deleteOrder
+  /orders/{id}/lines/{line}:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/orderId'
+        - name: line
+          in: path
+          required: true
+          schema:
+            $ref: '#/components/schemas/lineNo'
+        - name: status
+          in: query
+          required: true
+          schema:
+            type: string
+            enum: [open, closed]
+  /users/{userId}:
+    get:
+      parameters:
+        - name: userId
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+  /credits/{date}/{flag}:
+    post:
+      parameters:
+        - name: date
+          in: path
+          schema:
+            type: string
+            format: date
+        - name: flag
+          in: path
+          schema:
+            type: boolean
+  /codes/{code}:
+    get:
+      parameters:
+        - name: code
+          in: path
+          schema:
+            type: string
+            pattern: ^[A-Z]{3}$
+components:
+  parameters:
+    orderId:
+      name: id
+      in: path
+      required: true
+      schema:
+        type: integer
+  schemas:
+    lineNo:
+      type: number
+`
+
+func TestTemplatesFromOpenApi(t *testing.T) {
+	ts, err := TemplatesFromOpenApi([]byte(testOpenApiYaml))
+	require.NoError(t, err)
+	require.Equal(t, 6, len(ts))
+	expect := []struct {
+		method   string
+		path     string
+		opId     string
+		template string
+	}{
+		{http.MethodGet, `/codes/{code}`, "", `/codes/{code:[A-Z]{3}}`},
+		{http.MethodPost, `/credits/{date}/{flag}`, "", `/credits/{date:date}/{flag:bool}`},
+		{http.MethodGet, `/orders/{id}`, "getOrder", `/orders/{id:int}{?fields*:(name|total)}`},
+		{http.MethodDelete, `/orders/{id}`, "deleteOrder", `/orders/{id:int}`},
+		{http.MethodGet, `/orders/{id}/lines/{line}`, "", `/orders/{id:int}/lines/{line:float}{?status!:(open|closed)}`},
+		{http.MethodGet, `/users/{userId}`, "", `/users/{userId:uuid}`},
+	}
+	for i, e := range expect {
+		require.Equal(t, e.method, ts[i].Method)
+		require.Equal(t, e.path, ts[i].Path)
+		require.Equal(t, e.opId, ts[i].OperationId)
+		require.Equal(t, e.template, ts[i].Template.OriginalTemplate())
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, `/orders/12?fields=name&fields=total`, nil)
+	m, vars, ok := ts.MatchRequest(req)
+	require.True(t, ok)
+	require.Equal(t, "getOrder", m.OperationId)
+	require.Equal(t, 3, vars.Len())
+	id, _ := vars.GetTyped("id")
+	require.Equal(t, 12, id)
+	req, _ = http.NewRequest(http.MethodGet, `/orders/12?fields=other`, nil)
+	_, _, ok = ts.MatchRequest(req)
+	require.False(t, ok)
+	req, _ = http.NewRequest(http.MethodDelete, `/orders/x`, nil)
+	_, _, ok = ts.MatchRequest(req)
+	require.False(t, ok)
+	req, _ = http.NewRequest(http.MethodGet, `/orders/1/lines/2.5`, nil)
+	_, _, ok = ts.MatchRequest(req)
+	require.False(t, ok)
+	req, _ = http.NewRequest(http.MethodGet, `/orders/1/lines/2.5?status=open`, nil)
+	_, _, ok = ts.MatchRequest(req)
+	require.True(t, ok)
+}
+
+func TestTemplatesFromOpenApi_Json(t *testing.T) {
+	ts, err := TemplatesFromOpenApi([]byte(`{"paths": {"/users/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "schema": {"type": "integer"}}]}}}}`))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ts))
+	require.Equal(t, `/users/{id:int}`, ts[0].Template.OriginalTemplate())
+}
+
+func TestTemplatesFromOpenApiFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(testOpenApiYaml), 0644))
+	ts, err := TemplatesFromOpenApiFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(ts))
+
+	_, err = TemplatesFromOpenApiFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestTemplatesFromOpenApi_Errors(t *testing.T) {
+	testCases := []struct {
+		doc       string
+		expectErr string
+	}{
+		{`paths: [`, `invalid OpenAPI document: yaml: line 1: did not find expected node content`},
+		{`{"paths": {"/x/{id}": {"get": {"parameters": [{"$ref": "#/components/parameters/missing"}]}}}}`, `OpenAPI path '/x/{id}' (GET): unresolved parameter ref '#/components/parameters/missing'`},
+		{`{"paths": {"/x/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "schema": {"$ref": "#/components/schemas/missing"}}]}}}}`, `OpenAPI path '/x/{id}' (GET): unresolved schema ref '#/components/schemas/missing'`},
+		{`{"paths": {"/x/{id}": {"get": {"parameters": [null]}}}}`, `OpenAPI path '/x/{id}' (GET): invalid parameter`},
+		{`{"paths": {"/x/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "schema": {"pattern": "a**"}}]}}}}`, `OpenAPI path '/x/{id}' (GET): path var regexp problem`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectErr, func(t *testing.T) {
+			_, err := TemplatesFromOpenApi([]byte(tc.doc))
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package urit
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOpenApiPathsFrom(t *testing.T) {
+	paths, err := OpenApiPathsFrom(
+		MustCreateTemplate(`/orders/{id:[0-9]+}[/versions/{version}]{?fields*,expand!}`),
+		MustCreateTemplate(`/credits/?/?`),
+		MustCreateTemplate(`/files/{id}-{name}/{rest...}`),
+		MustCreateTemplate(`https://{tenant}.example.com/proxy/*`),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 7, len(paths))
+	explode := true
+	require.Equal(t, []OpenApiParameter{
+		{Name: "id", In: "path", Required: true, Schema: &OpenApiSchema{Type: "string", Pattern: "^[0-9]+$"}},
+		{Name: "fields", In: "query", Explode: &explode, Schema: &OpenApiSchema{Type: "array", Items: &OpenApiSchema{Type: "string"}}},
+		{Name: "expand", In: "query", Required: true, Schema: &OpenApiSchema{Type: "string"}},
+	}, paths["/orders/{id}"].Parameters)
+	require.Equal(t, 4, len(paths["/orders/{id}/versions/{version}"].Parameters))
+	require.Equal(t, []OpenApiParameter{
+		{Name: "var1", In: "path", Required: true, Schema: &OpenApiSchema{Type: "string"}},
+		{Name: "var2", In: "path", Required: true, Schema: &OpenApiSchema{Type: "string"}},
+	}, paths["/credits/{var1}/{var2}"].Parameters)
+	require.Equal(t, 2, len(paths["/files/{id}-{name}"].Parameters))
+	require.Equal(t, 3, len(paths["/files/{id}-{name}/{rest}"].Parameters))
+	require.Equal(t, "remaining path segments", paths["/files/{id}-{name}/{rest}"].Parameters[2].Description)
+	require.Equal(t, 0, len(paths["/proxy"].Parameters))
+	require.Equal(t, 1, len(paths["/proxy/{catchAll}"].Parameters))
+
+	data, err := json.Marshal(paths["/credits/{var1}/{var2}"])
+	require.NoError(t, err)
+	require.Equal(t, `{"parameters":[{"name":"var1","in":"path","required":true,"schema":{"type":"string"}},{"name":"var2","in":"path","required":true,"schema":{"type":"string"}}]}`, string(data))
+}
+
+func TestOpenApiPathsFrom_Merges(t *testing.T) {
+	paths, err := OpenApiPathsFrom(
+		MustCreateTemplate(`/search{?q}`),
+		MustCreateTemplate(`/search{?q,page}`),
+		MustCreateTemplate(`[/search]`),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(paths))
+	require.Equal(t, 2, len(paths["/search"].Parameters))
+	require.Equal(t, 0, len(paths["/"].Parameters))
+}
+
+func TestOpenApiPathsFrom_Errors(t *testing.T) {
+	_, err := OpenApiPathsFrom(MustCreateRfc6570Template(`/users{/id}`))
+	require.Error(t, err)
+	require.Equal(t, `cannot convert template '/users{/id}' to OpenAPI`, err.Error())
+}
@@ -0,0 +1,175 @@
+package urit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// templateChunk is a chunk of template path - either the required path (group 0) or an optional group
+type templateChunk struct {
+	text  string
+	group int
+	pos   int
+}
+
+// splitOptionalGroups splits the template path into the required path and any trailing optional groups -
+// e.g. `/orders/{id}[/{version}[/{part}]]` or `/orders/{id}[/{version}][/{part}]`
+//
+// optional groups are numbered in order (1, 2, ...) - a later group can only be present if all earlier groups are present
+func splitOptionalGroups(s string) ([]templateChunk, error) {
+	result := make([]templateChunk, 0)
+	depth := 0
+	var quote byte
+	open := 0
+	group := 0
+	afterClose := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			i++
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case depth > 0:
+			if c == '{' || c == '(' {
+				depth++
+			} else if c == '}' || c == ')' {
+				depth--
+			}
+			continue
+		case c == '[':
+			if i+1 >= len(s) || s[i+1] != '/' {
+				return nil, newTemplateParseError(fmt.Sprintf("optional group must start with '/' (position %d)", i), i, nil)
+			}
+			result = append(result, templateChunk{text: s[start:i], group: group, pos: start})
+			group++
+			open++
+			start = i + 1
+			afterClose = false
+			continue
+		case c == ']':
+			if open == 0 {
+				return nil, newTemplateParseError(fmt.Sprintf("unopened ']' at position %d", i), i, nil)
+			}
+			result = append(result, templateChunk{text: s[start:i], group: group, pos: start})
+			open--
+			start = i + 1
+			afterClose = true
+			continue
+		}
+		if afterClose || (group > 0 && open == 0) {
+			return nil, newTemplateParseError(fmt.Sprintf("optional groups must be at end of template (position %d)", i), i, nil)
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+		} else if c == '{' || c == '(' {
+			depth++
+		}
+	}
+	if open > 0 {
+		return nil, newTemplateParseError("unclosed optional group", len(s), nil)
+	}
+	if start < len(s) || group == 0 {
+		result = append(result, templateChunk{text: s[start:], group: group, pos: start})
+	}
+	return result, nil
+}
+
+// allowsSegmentCount determines whether the number of path segments is allowed by the template (taking into account optional groups)
+func (t *template) allowsSegmentCount(n int) bool {
+	return n == len(t.pathParts) || (n >= 0 && n < len(t.pathParts) && t.isGroupBoundary(n)) ||
+		(t.hasCatchAll() && n >= len(t.pathParts)-1)
+}
+
+// isGroupBoundary determines whether the path part index is the start of an optional group
+func (t *template) isGroupBoundary(i int) bool {
+	return t.pathParts[i].group > 0 && (i == 0 || t.pathParts[i-1].group != t.pathParts[i].group)
+}
+
+func (t *template) hasOptionalGroups() bool {
+	return len(t.pathParts) > 0 && t.pathParts[len(t.pathParts)-1].group > 0
+}
+
+// optionalGroupsUpTo determines the last optional group that can be generated from the vars
+//
+// a group is generated if all its vars are present (and all the vars of the preceding groups are present) - a group
+// with no vars is only generated if a later group is generated
+//
+// returns an error if there are vars present for a group that cannot be generated (because a var in a preceding
+// group is not present) - hostVars specifies whether any positional vars for the host are in the vars
+func (t *template) optionalGroupsUpTo(vars PathVars, hostVars bool) (int, error) {
+	result := 0
+	posn := 0
+	if hostVars {
+		posn = t.host.positionalVarsCount()
+	}
+	namePosns := map[string]int{}
+	present := func(pt *pathPart) bool {
+		if pt.name == "" {
+			posn++
+			return vars != nil && vars.VarsType() == Positions && posn <= vars.Len()
+		}
+		np := namePosns[pt.name]
+		namePosns[pt.name] = np + 1
+		if vars == nil || vars.VarsType() != Names {
+			return false
+		}
+		_, ok := vars.GetNamed(pt.name, np)
+		return ok
+	}
+	groupOk := true
+	groupHasVars := false
+	var missing *pathPart
+	for i := range t.pathParts {
+		pt := &t.pathParts[i]
+		if t.isGroupBoundary(i) {
+			if groupOk && groupHasVars {
+				result = pt.group - 1
+			}
+			groupHasVars = false
+		}
+		for _, vp := range pt.varParts() {
+			groupHasVars = true
+			if !present(vp) {
+				if groupOk && pt.group > 0 {
+					missing = vp
+				}
+				groupOk = false
+			} else if missing != nil {
+				return 0, fmt.Errorf("var '%s' cannot be used without var '%s' (optional group)", vp.name, missing.name)
+			}
+		}
+	}
+	if groupOk && groupHasVars && len(t.pathParts) > 0 {
+		result = t.pathParts[len(t.pathParts)-1].group
+	}
+	return result, nil
+}
+
+// varParts returns the var path parts (either the path part itself or any var sub-parts)
+func (pt *pathPart) varParts() []*pathPart {
+	result := make([]*pathPart, 0)
+	if pt.fixed {
+		return result
+	} else if len(pt.subParts) == 0 {
+		return append(result, pt)
+	}
+	for i := range pt.subParts {
+		if !pt.subParts[i].fixed {
+			result = append(result, &pt.subParts[i])
+		}
+	}
+	return result
+}
+
+// writeGroupBrackets writes the opening brackets for optional groups (when moving from one group to another)
+func writeGroupBrackets(builder *strings.Builder, from int, to int) {
+	for g := from; g < to; g++ {
+		builder.WriteString("[")
+	}
+}
@@ -0,0 +1,232 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTemplate_OptionalGroups_Parse(t *testing.T) {
+	testCases := []struct {
+		template     string
+		expectGroups []int
+		expectNoPats string
+	}{
+		{`/orders/{id}[/{version}]`, []int{0, 0, 1}, `/orders/{id}[/{version}]`},
+		{`/orders/{id:[0-9]+}[/{version:[0-9]+}]`, []int{0, 0, 1}, `/orders/{id}[/{version}]`},
+		{`/orders/{id}[/versions/{version}[/{part}]]`, []int{0, 0, 1, 1, 2}, `/orders/{id}[/versions/{version}[/{part}]]`},
+		{`/orders/{id}[/{version}][/{part}]`, []int{0, 0, 1, 2}, `/orders/{id}[/{version}[/{part}]]`},
+		{`/orders/{id}[/'[x]']`, []int{0, 0, 1}, `/orders/{id}[/[x]]`},
+		{`[/orders]`, []int{1}, `[/orders]`},
+		{`/orders[/?]`, []int{0, 1}, `/orders[/{}]`},
+		{`/orders/{id}[/{version}]{?fields}`, []int{0, 0, 1}, `/orders/{id}[/{version}]{?fields}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmp, err := NewTemplate(tc.template)
+			require.NoError(t, err)
+			rt := tmp.(*template)
+			groups := make([]int, 0)
+			for _, pt := range rt.pathParts {
+				groups = append(groups, pt.group)
+			}
+			require.Equal(t, tc.expectGroups, groups)
+			require.Equal(t, tc.expectNoPats, tmp.Template(true))
+		})
+	}
+}
+
+func TestTemplate_OptionalGroups_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template  string
+		expectErr string
+	}{
+		{`/orders/{id}[{version}]`, `optional group must start with '/' (position 12)`},
+		{`/orders/{id}[]`, `optional group must start with '/' (position 12)`},
+		{`/orders/{id}[/{version}]/more`, `optional groups must be at end of template (position 24)`},
+		{`/orders/{id}[/{version}[/{part}]/more]`, `optional groups must be at end of template (position 32)`},
+		{`/orders/{id}[/{version}`, `unclosed optional group`},
+		{`/orders/{id}]`, `unopened ']' at position 12`},
+		{`/orders/{id}[/]`, `optional group cannot be empty`},
+		{`/orders/{id}[/[/{version}]]`, `optional group cannot be empty`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewTemplate(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestTemplate_OptionalGroups_Matches(t *testing.T) {
+	testCases := []struct {
+		template   string
+		path       string
+		expectOk   bool
+		expectVars map[string]string
+	}{
+		{`/orders/{id}[/{version}]`, `/orders/1`, true, map[string]string{"id": "1"}},
+		{`/orders/{id}[/{version}]`, `/orders/1/2`, true, map[string]string{"id": "1", "version": "2"}},
+		{`/orders/{id}[/{version}]`, `/orders/1/2/3`, false, nil},
+		{`/orders/{id}[/{version}]`, `/orders`, false, nil},
+		{`/orders/{id}[/versions/{version}[/{part}]]`, `/orders/1/versions`, false, nil},
+		{`/orders/{id}[/versions/{version}[/{part}]]`, `/orders/1/versions/2`, true, map[string]string{"id": "1", "version": "2"}},
+		{`/orders/{id}[/versions/{version}[/{part}]]`, `/orders/1/versions/2/3`, true, map[string]string{"id": "1", "version": "2", "part": "3"}},
+		{`/orders/{id}[/versions/{version}[/{part}]]`, `/orders/1/other/2`, false, nil},
+		{`/orders/{id}[/{version:[0-9]+}]`, `/orders/1/x`, false, nil},
+		{`[/orders]`, `/`, true, map[string]string{}},
+		{`[/orders]`, `/orders`, true, map[string]string{}},
+		{`/orders/{id}[/{version}]{?fields}`, `/orders/1?fields=a`, true, map[string]string{"id": "1", "fields": "a"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				require.Equal(t, len(tc.expectVars), vars.Len())
+				for k, v := range tc.expectVars {
+					av, ok := vars.Get(k)
+					require.True(t, ok)
+					require.Equal(t, v, av)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplate_OptionalGroups_PathFrom(t *testing.T) {
+	tmp := MustCreateTemplate(`/orders/{id}[/versions/{version}[/{part}]]`)
+	pth, err := tmp.PathFrom(Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, pth)
+	pth, err = tmp.PathFrom(Named("id", 1, "version", 2))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1/versions/2`, pth)
+	pth, err = tmp.PathFrom(Named("id", 1, "version", 2, "part", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1/versions/2/3`, pth)
+	_, err = tmp.PathFrom(Named("id", 1, "part", 3))
+	require.Error(t, err)
+	require.Equal(t, `var 'part' cannot be used without var 'version' (optional group)`, err.Error())
+	_, err = tmp.PathFrom(Named("version", 2))
+	require.Error(t, err)
+	require.Equal(t, `no var for 'id'`, err.Error())
+
+	tmp = MustCreateTemplate(`/a[/{b}[/{c}]]`)
+	_, err = tmp.PathFrom(Named("c", 1))
+	require.Error(t, err)
+	require.Equal(t, `var 'c' cannot be used without var 'b' (optional group)`, err.Error())
+	tmp = MustCreateTemplate(`/a[/{b}-{c}]`)
+	_, err = tmp.PathFrom(Named("c", 1))
+	require.Error(t, err)
+	require.Equal(t, `var 'c' cannot be used without var 'b' (optional group)`, err.Error())
+
+	tmp = MustCreateTemplate(`/orders/{id}[/latest][/{part}]`)
+	pth, err = tmp.PathFrom(Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, pth)
+	pth, err = tmp.PathFrom(Named("id", 1, "part", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1/latest/3`, pth)
+
+	tmp = MustCreateTemplate(`/orders/?[/?]`)
+	pth, err = tmp.PathFrom(Positional(1))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, pth)
+	pth, err = tmp.PathFrom(Positional(1, 2))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1/2`, pth)
+
+	tmp = MustCreateTemplate(`/orders/{id}[/{id}-{sub}]`)
+	pth, err = tmp.PathFrom(Named("id", 1, "id", 2))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, pth)
+	pth, err = tmp.PathFrom(Named("id", 1, "id", 2, "sub", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1/2-3`, pth)
+
+	pth, err = tmp.PathFromStruct(struct {
+		Id int `urit:"id"`
+	}{Id: 1})
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, pth)
+}
+
+func TestTemplate_OptionalGroups_Vars(t *testing.T) {
+	tmp := MustCreateTemplate(`/orders/{id}[/{version}-{sub}]`)
+	require.Equal(t, []PathVar{
+		{Name: "id", Position: 0},
+		{Name: "version", Position: 1, Optional: true},
+		{Name: "sub", Position: 2, Optional: true},
+	}, tmp.Vars())
+}
+
+func TestTemplate_OptionalGroups_SubAndResolveTo(t *testing.T) {
+	tmp := MustCreateTemplate(`/orders/{id}[/{version}[/{part}-{x}]]`)
+	_, err := tmp.Sub(`/more`)
+	require.Error(t, err)
+	require.Equal(t, `cannot add sub-path to template with optional groups`, err.Error())
+
+	tmp2, err := MustCreateTemplate(`/orders`).Sub(`/{id}[/{version}]`)
+	require.NoError(t, err)
+	require.Equal(t, `/orders/{id}[/{version}]`, tmp2.OriginalTemplate())
+	_, ok := tmp2.Matches(`/orders/1/2`)
+	require.True(t, ok)
+
+	tmp3, err := tmp.ResolveTo(Named("id", 1, "version", 2, "part", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1[/2[/3-{x}]]`, tmp3.OriginalTemplate())
+	_, ok = tmp3.Matches(`/orders/1`)
+	require.True(t, ok)
+	_, ok = tmp3.Matches(`/orders/1/2/3-4`)
+	require.True(t, ok)
+	_, ok = tmp3.Matches(`/orders/1/3`)
+	require.False(t, ok)
+
+	tmp3, err = tmp.ResolveTo(Named("x", 4, "part", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/{id}[/{version}[/3-4]]`, tmp3.OriginalTemplate())
+}
+
+func TestRouter_OptionalGroups(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/orders/{id}[/{version}]`), "order"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/orders/{id}/{version}/{part}`), "part"))
+	err := r.Add(MustCreateTemplate(`/orders/{order-id}`), nil)
+	require.Error(t, err)
+	require.Equal(t, `template '/orders/{order-id}' is shadowed by template '/orders/{id}[/{version}]'`, err.Error())
+	require.Equal(t, 2, r.Len())
+
+	m, ok := r.Match(`/orders/1`)
+	require.True(t, ok)
+	require.Equal(t, "order", m.Payload)
+	m, ok = r.Match(`/orders/1/2`)
+	require.True(t, ok)
+	require.Equal(t, "order", m.Payload)
+	m, ok = r.Match(`/orders/1/2/3`)
+	require.True(t, ok)
+	require.Equal(t, "part", m.Payload)
+	_, ok = r.Match(`/orders`)
+	require.False(t, ok)
+}
+
+func TestRouter_OptionalGroups_PartiallyShadowed(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}`), "user"))
+	// only shadowed without the optional group - so added...
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}[/{v}]`), "version"))
+	require.Equal(t, 2, r.Len())
+	err := r.Add(MustCreateTemplate(`/users/{uid}[/{version}]`), nil)
+	require.Error(t, err)
+	require.Equal(t, `template '/users/{uid}[/{version}]' is shadowed by template '/users/{id}[/{v}]'`, err.Error())
+
+	m, ok := r.Match(`/users/x`)
+	require.True(t, ok)
+	require.Equal(t, "user", m.Payload)
+	m, ok = r.Match(`/users/x/y`)
+	require.True(t, ok)
+	require.Equal(t, "version", m.Payload)
+	v, _ := m.Vars.Get("v")
+	require.Equal(t, "y", v)
+}
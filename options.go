@@ -1,6 +1,7 @@
 package urit
 
 import (
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -21,13 +22,41 @@ type VarMatchOption interface {
 	Match(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) (string, bool)
 }
 
+// EncodingOption is the option interface for encoding path var values (when generating paths)
+// and decoding path segments (when matching)
+//
+// By default, path var values (and fixed path parts) are percent-encoded according to RFC 3986 path segment rules (and
+// path segments are percent-decoded when matching) - the PreEncoded option can be used where path var
+// values are already encoded (fixed path parts are then used as written in the template)
+type EncodingOption interface {
+	Encode(value string) string
+	Decode(value string) (string, bool)
+}
+
+// SubPartsOption is the option interface for controlling how unconstrained vars in path segments with
+// multiple parts (e.g. `{a}-{b}`) are matched
+//
+// By default, unconstrained sub-part vars are matched greedily (e.g. for `{a}-{b}`, the path segment `x-y-z` gives
+// a = `x-y` and b = `z`) - the NonGreedySubParts option can be used with NewTemplate to match them non-greedily (giving
+// a = `x` and b = `y-z`)
+type SubPartsOption interface {
+	Greedy() bool
+}
+
 var (
 	_CaseInsensitiveFixed = &caseInsensitiveFixed{}
 	_PathRegexCheck       = &pathRegexChecker{}
+	_PreEncoded           = &preEncoded{}
+	_PercentEncoded       = &percentEncoded{}
+	_GreedySubParts       = &subPartsOption{greedy: true}
+	_NonGreedySubParts    = &subPartsOption{greedy: false}
 )
 var (
 	CaseInsensitiveFixed = _CaseInsensitiveFixed // is a FixedMatchOption that can be used with templates to allow case-insensitive fixed path parts
 	PathRegexCheck       = _PathRegexCheck       // is a VarMatchOption that can be used with Template.PathFrom or Template.RequestFrom to check that vars passed in match regexes for the path part
+	GreedySubParts       = _GreedySubParts       // is a SubPartsOption that can be used with NewTemplate to match unconstrained vars in path segments with multiple parts greedily (the default)
+	NonGreedySubParts    = _NonGreedySubParts    // is a SubPartsOption that can be used with NewTemplate to match unconstrained vars in path segments with multiple parts non-greedily
+	PreEncoded           = _PreEncoded           // is an EncodingOption that can be used with Template.PathFrom or Template.RequestFrom to indicate that path var values are already encoded (and with Template.Matches to leave path segments encoded)
 )
 
 type fixedMatchOptions []FixedMatchOption
@@ -76,6 +105,54 @@ func (o *pathRegexChecker) Applicable(value string, position int, name string, r
 func (o *pathRegexChecker) Match(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) (string, bool) {
 	if rx != nil && !rx.MatchString(value) {
 		return value, false
+	} else if vt, args, _, err := parseVarType(rxs); err == nil && vt != nil {
+		// var types (e.g. `{d:date}`) also check that the value is convertible...
+		if _, err = vt.Convert(value, args); err != nil {
+			return value, false
+		}
 	}
 	return value, true
 }
+
+type subPartsOption struct {
+	greedy bool
+}
+
+func (o *subPartsOption) Greedy() bool {
+	return o.greedy
+}
+
+// nonGreedySubParts determines whether the options specify non-greedy sub-parts (the last SubPartsOption wins)
+func nonGreedySubParts(options []interface{}) bool {
+	result := false
+	for _, o := range options {
+		if sp, ok := o.(SubPartsOption); ok {
+			result = !sp.Greedy()
+		}
+	}
+	return result
+}
+
+type preEncoded struct{}
+
+func (o *preEncoded) Encode(value string) string {
+	return value
+}
+
+func (o *preEncoded) Decode(value string) (string, bool) {
+	return value, true
+}
+
+type percentEncoded struct{}
+
+func (o *percentEncoded) Encode(value string) string {
+	return escapePathSegment(value)
+}
+
+func (o *percentEncoded) Decode(value string) (string, bool) {
+	if strings.IndexByte(value, '%') == -1 {
+		return value, true
+	}
+	s, err := url.PathUnescape(value)
+	return s, err == nil
+}
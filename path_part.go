@@ -17,6 +17,10 @@ type pathPart struct {
 	allRegexp     *regexp.Regexp
 	allRegexpIdxs map[int]int
 	name          string
+	group         int  // the optional group the part belongs to (0 if not optional)
+	catchAll      bool // whether the part is a catch-all var (matching all remaining path segments)
+	nonGreedy     bool // whether unconstrained sub-part vars are matched non-greedily (see NonGreedySubParts)
+	typedVar
 }
 
 func (pt *pathPart) setName(name string, pos int) error {
@@ -27,7 +31,12 @@ func (pt *pathPart) setName(name string, pos int) error {
 		}
 		pt.orgRegexp = strings.Trim(name[cAt+1:], " ")
 		if pt.orgRegexp != "" {
-			rxBit := addRegexHeadAndTail(pt.orgRegexp)
+			vt, args, rxs, err := parseVarType(pt.orgRegexp)
+			if err != nil {
+				return newTemplateParseError("path var type problem", pos+cAt, err)
+			}
+			pt.varType, pt.varTypeArgs = vt, args
+			rxBit := addRegexHeadAndTail(rxs)
 			if rx, err := regexp.Compile(rxBit); err == nil {
 				pt.regexp = rx
 			} else {
@@ -37,13 +46,17 @@ func (pt *pathPart) setName(name string, pos int) error {
 	} else {
 		pt.name = strings.Trim(name, " ")
 	}
+	if strings.HasSuffix(pt.name, "...") {
+		pt.catchAll = true
+		pt.name = strings.TrimRight(pt.name[:len(pt.name)-3], " ")
+	}
 	if pt.name == "" {
 		return newTemplateParseError("path var name cannot be empty", pos, nil)
 	}
 	return nil
 }
 
-func (pt *pathPart) addFound(vars PathVars, val string) {
+func (pt *pathPart) addFound(vars PathVars, val interface{}) {
 	if pt.name != "" {
 		_ = vars.AddNamedValue(pt.name, val)
 	} else {
@@ -53,7 +66,7 @@ func (pt *pathPart) addFound(vars PathVars, val string) {
 
 func (pt *pathPart) match(s string, pathPos int, vars PathVars, fOpts fixedMatchOptions, vOpts varMatchOptions) bool {
 	if pt.fixed {
-		ok := pt.fixedValue == s
+		ok := pt.fixedValue == s || pt.decodedFixedValue() == s
 		if len(fOpts) > 0 {
 			ok = fOpts.check(s, pt.fixedValue, pathPos, vars)
 		}
@@ -66,8 +79,13 @@ func (pt *pathPart) match(s string, pathPos int, vars PathVars, fOpts fixedMatch
 				ok = vok
 			}
 		}
+		var tv interface{}
+		if ok {
+			tv, ok = pt.convertTyped(s)
+		}
 		if ok {
 			pt.addFound(vars, s)
+			setLastTypedValue(vars, tv)
 			return true
 		}
 	} else {
@@ -76,59 +94,106 @@ func (pt *pathPart) match(s string, pathPos int, vars PathVars, fOpts fixedMatch
 	return false
 }
 
+// decodedFixedValue returns the fixed value with any percent-encoding decoded
+func (pt *pathPart) decodedFixedValue() string {
+	if s, ok := _PercentEncoded.Decode(pt.fixedValue); ok {
+		return s
+	}
+	return pt.fixedValue
+}
+
 func (pt *pathPart) multiMatch(s string, pathPos int, vars PathVars, vOpts varMatchOptions) bool {
-	orx := pt.overallRegexp()
+	orx, idxs := pt.overallRegexpAndIdxs()
 	sms := orx.FindStringSubmatch(s)
-	if len(sms) > 0 {
-		for i, sp := range pt.subParts {
-			if !sp.fixed {
-				str := sms[pt.allRegexpIdxs[i]]
-				ok := true
-				if len(vOpts) > 0 {
-					if rs, vok, applicable := vOpts.check(str, vars.Len(), sp.name, sp.regexp, sp.orgRegexp, pathPos, vars); applicable {
-						str = rs
-						ok = vok
-					}
-				}
-				if ok {
-					sp.addFound(vars, str)
+	if len(sms) == 0 {
+		return false
+	}
+	for i, sp := range pt.subParts {
+		if !sp.fixed {
+			str := sms[idxs[i]]
+			ok := true
+			if len(vOpts) > 0 {
+				if rs, vok, applicable := vOpts.check(str, vars.Len(), sp.name, sp.regexp, sp.orgRegexp, pathPos, vars); applicable {
+					str = rs
+					ok = vok
 				}
 			}
+			var tv interface{}
+			if ok {
+				tv, ok = sp.convertTyped(str)
+			}
+			if !ok {
+				return false
+			}
+			sp.addFound(vars, str)
+			setLastTypedValue(vars, tv)
 		}
-		return true
 	}
-	return false
+	return true
 }
 
+// overallRegexp returns the regexp for matching the whole path segment (for a path part with multiple sub-parts)
 func (pt *pathPart) overallRegexp() *regexp.Regexp {
-	if pt.allRegexp == nil {
-		var rxb strings.Builder
-		for i, sp := range pt.subParts {
-			if sp.fixed {
-				rxb.WriteString(`(\Q` + sp.fixedValue + `\E)`)
-			} else if sp.orgRegexp != "" {
-				rxb.WriteString(`(?P<vsp` + fmt.Sprintf("%d", i) + `>` + stripRegexHeadAndTail(sp.orgRegexp) + `)`)
+	rx, _ := pt.overallRegexpAndIdxs()
+	return rx
+}
+
+// overallRegexpAndIdxs returns the overall regexp and the sub-match indexes of the sub-parts
+//
+// the overall regexp is compiled when the template is created (see pathPart.precompile) - for a path part that has
+// not been precompiled, it is compiled on each call (so that path parts are never modified when matching)
+func (pt *pathPart) overallRegexpAndIdxs() (*regexp.Regexp, map[int]int) {
+	if pt.allRegexp != nil {
+		return pt.allRegexp, pt.allRegexpIdxs
+	}
+	return pt.compileOverallRegexp()
+}
+
+func (pt *pathPart) compileOverallRegexp() (*regexp.Regexp, map[int]int) {
+	var rxb strings.Builder
+	for i, sp := range pt.subParts {
+		if sp.fixed {
+			// path segments are matched decoded (unless pre-encoded)...
+			if dv := sp.decodedFixedValue(); dv != sp.fixedValue {
+				rxb.WriteString(`(` + regexp.QuoteMeta(dv) + `|` + regexp.QuoteMeta(sp.fixedValue) + `)`)
 			} else {
-				rxb.WriteString(`(?P<vsp` + fmt.Sprintf("%d", i) + `>.*)`)
+				rxb.WriteString(`(` + regexp.QuoteMeta(dv) + `)`)
 			}
+		} else if sp.regexp != nil {
+			rxb.WriteString(`(?P<vsp` + fmt.Sprintf("%d", i) + `>` + sp.regexpSource() + `)`)
+		} else if pt.nonGreedy {
+			rxb.WriteString(`(?P<vsp` + fmt.Sprintf("%d", i) + `>.*?)`)
+		} else {
+			rxb.WriteString(`(?P<vsp` + fmt.Sprintf("%d", i) + `>.*)`)
 		}
-		if rx, err := regexp.Compile(addRegexHeadAndTail(rxb.String())); err == nil {
-			pt.allRegexp = rx
-			pt.allRegexpIdxs = map[int]int{}
-			for i, nm := range rx.SubexpNames() {
-				if i > 0 && nm != "" && strings.HasPrefix(nm, "vsp") {
-					nmi, _ := strconv.Atoi(nm[3:])
-					pt.allRegexpIdxs[nmi] = i
-				}
-			}
+	}
+	rx, err := regexp.Compile(addRegexHeadAndTail(rxb.String()))
+	if err != nil {
+		return nil, nil
+	}
+	idxs := map[int]int{}
+	for i, nm := range rx.SubexpNames() {
+		if i > 0 && nm != "" && strings.HasPrefix(nm, "vsp") {
+			nmi, _ := strconv.Atoi(nm[3:])
+			idxs[nmi] = i
 		}
 	}
-	return pt.allRegexp
+	return rx, idxs
+}
+
+// regexpSource returns the regexp source (without head and tail anchors) - for var types, the var type regexp
+func (pt *pathPart) regexpSource() string {
+	if pt.regexp == nil {
+		return ""
+	}
+	return stripRegexHeadAndTail(pt.regexp.String())
 }
 
 func (pt *pathPart) pathFrom(tracker *positionsTracker) (string, error) {
 	if pt.fixed {
-		return `/` + pt.fixedValue, nil
+		return `/` + tracker.encodeFixed(pt.fixedValue), nil
+	} else if pt.catchAll {
+		return tracker.catchAllFrom(pt)
 	} else if len(pt.subParts) == 0 {
 		if str, err := tracker.getVar(pt); err == nil {
 			return `/` + str, nil
@@ -139,7 +204,7 @@ func (pt *pathPart) pathFrom(tracker *positionsTracker) (string, error) {
 	var pb strings.Builder
 	for _, sp := range pt.subParts {
 		if sp.fixed {
-			pb.WriteString(sp.fixedValue)
+			pb.WriteString(tracker.encodeFixed(sp.fixedValue))
 		} else if str, err := tracker.getVar(&sp); err == nil {
 			pb.WriteString(str)
 		} else {
@@ -164,6 +229,7 @@ func (pt *pathPart) getVars(vars []PathVar, namePosns map[string]int) []PathVar
 				Name:          pt.name,
 				NamedPosition: namePosns[pt.name],
 				Position:      len(vars),
+				Optional:      pt.catchAll,
 			})
 			namePosns[pt.name] = namePosns[pt.name] + 1
 		}
@@ -178,6 +244,10 @@ func (pt *pathPart) buildNoPattern(builder *strings.Builder) {
 		for _, sp := range pt.subParts {
 			sp.buildNoPattern(builder)
 		}
+	} else if pt.catchAll && pt.name == catchAllName {
+		builder.WriteString(catchAllName)
+	} else if pt.catchAll {
+		builder.WriteString("{" + pt.name + "...}")
 	} else {
 		builder.WriteString("{" + pt.name + "}")
 	}
@@ -189,6 +259,7 @@ type positionsTracker struct {
 	pathPosition   int
 	namedPositions map[string]int
 	varMatches     varMatchOptions
+	encoding       EncodingOption
 }
 
 func (tr *positionsTracker) getVar(pt *pathPart) (string, error) {
@@ -200,7 +271,7 @@ func (tr *positionsTracker) getVar(pt *pathPart) (string, error) {
 	if useVars.VarsType() == Positions {
 		if str, ok := useVars.GetPositional(tr.varPosition); ok {
 			tr.varPosition++
-			return str, nil
+			return tr.encode(str), nil
 		}
 		return "", fmt.Errorf("no var for varPosition %d", tr.varPosition+1)
 	} else {
@@ -212,7 +283,7 @@ func (tr *positionsTracker) getVar(pt *pathPart) (string, error) {
 			}
 			tr.namedPositions[pt.name] = np + 1
 			tr.varPosition++
-			return str, nil
+			return tr.encode(str), nil
 		} else if np == 0 {
 			return "", fmt.Errorf("no var for '%s'", pt.name)
 		}
@@ -220,6 +291,26 @@ func (tr *positionsTracker) getVar(pt *pathPart) (string, error) {
 	}
 }
 
+func (tr *positionsTracker) encode(s string) string {
+	if tr.encoding == nil {
+		return _PercentEncoded.Encode(s)
+	}
+	return tr.encoding.Encode(s)
+}
+
+// encodeFixed encodes a fixed value (which may already be encoded in the template) - by decoding and re-encoding
+// it using the encoding (so, with PreEncoded, fixed values are used as is)
+func (tr *positionsTracker) encodeFixed(s string) string {
+	encoding := tr.encoding
+	if encoding == nil {
+		encoding = _PercentEncoded
+	}
+	if ds, ok := encoding.Decode(s); ok {
+		return encoding.Encode(ds)
+	}
+	return s
+}
+
 func (tr *positionsTracker) checkVar(s string, pt *pathPart, pos int, pathPos int) (result string, err error) {
 	result = s
 	for _, ck := range tr.varMatches {
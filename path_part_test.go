@@ -160,3 +160,66 @@ func (o *fooUpperChecker) Match(value string, position int, name string, rx *reg
 func (o *fooUpperChecker) Applicable(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) bool {
 	return name == "foo"
 }
+
+func TestPathPart_MultiMatch_Rejected(t *testing.T) {
+	tmp := MustCreateTemplate(`/files/{name}-{version}.{ext}`)
+	vars, ok := tmp.Matches(`/files/foo-1.txt`)
+	require.True(t, ok)
+	require.Equal(t, 3, vars.Len())
+
+	_, ok = tmp.Matches(`/files/foo-1.txt`, &rejectVar{})
+	require.False(t, ok)
+	vars, ok = tmp.Matches(`/files/foo-v1.txt`, &rejectVar{})
+	require.True(t, ok)
+	require.Equal(t, 3, vars.Len())
+
+	r := tmp.MatchDetailed(`/files/foo-1.txt`, &rejectVar{})
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchOption, r.Reason)
+	require.Equal(t, 1, r.SegmentIndex)
+	require.Equal(t, "1", r.Actual)
+
+	tmp = MustCreateTemplate(`/files/{name}-{version:int}`)
+	_, ok = tmp.Matches(`/files/foo-99999999999999999999`)
+	require.False(t, ok)
+}
+
+func TestPathPart_MultiMatch_Greedy(t *testing.T) {
+	tmp := MustCreateTemplate(`/{a}-{b}`)
+	vars, ok := tmp.Matches(`/x-y-z`)
+	require.True(t, ok)
+	a, _ := vars.Get("a")
+	b, _ := vars.Get("b")
+	require.Equal(t, "x-y", a)
+	require.Equal(t, "z", b)
+
+	tmp = MustCreateTemplate(`/{a}-{b}`, GreedySubParts)
+	vars, ok = tmp.Matches(`/x-y-z`)
+	require.True(t, ok)
+	a, _ = vars.Get("a")
+	require.Equal(t, "x-y", a)
+
+	tmp = MustCreateTemplate(`/{a}-{b}`, NonGreedySubParts)
+	vars, ok = tmp.Matches(`/x-y-z`)
+	require.True(t, ok)
+	a, _ = vars.Get("a")
+	b, _ = vars.Get("b")
+	require.Equal(t, "x", a)
+	require.Equal(t, "y-z", b)
+
+	// constrained sub-parts are unaffected...
+	tmp = MustCreateTemplate(`/{a:[a-z-]+}-{b}`, NonGreedySubParts)
+	vars, ok = tmp.Matches(`/x-y-z`)
+	require.True(t, ok)
+	a, _ = vars.Get("a")
+	require.Equal(t, "x-y", a)
+
+	// and non-greedy is retained by resolved templates...
+	tmp = MustCreateTemplate(`/{c}/{a}-{b}`, NonGreedySubParts)
+	rt, err := tmp.ResolveTo(Named("c", "foo"))
+	require.NoError(t, err)
+	vars, ok = rt.Matches(`/foo/x-y-z`)
+	require.True(t, ok)
+	a, _ = vars.Get("a")
+	require.Equal(t, "x", a)
+}
@@ -1,23 +1,49 @@
 package urit
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type PathVar struct {
 	Name          string
 	NamedPosition int
 	Position      int
 	Value         interface{}
+	// TypedValue is the converted value (for vars matched against a var type - e.g. `{id:int}`)
+	TypedValue interface{}
+	// Optional is set (for Template.Vars) where the var is optional (i.e. in an optional group or an optional query param)
+	Optional bool
 }
 
 // PathVars is the interface used to pass path vars into a template and returned from a template after extracting
 //
-// Use either Positional or Named to create a new PathVars
+// Use either Positional or Named to create a new PathVars (PathVars is not intended to be implemented outside
+// urit - and may gain new methods)
 type PathVars interface {
 	GetPositional(position int) (string, bool)
 	GetNamed(name string, position int) (string, bool)
 	GetNamedFirst(name string) (string, bool)
 	GetNamedLast(name string) (string, bool)
 	Get(idents ...interface{}) (string, bool)
+	// GetSegments gets the path var (by position, name or name and position - same as Get) as Segments
+	//
+	// for a catch-all var, returns the captured path segments - for any other var, returns the single value as Segments
+	GetSegments(idents ...interface{}) (Segments, bool)
+	// GetTyped gets the path var (by position, name or name and position - same as Get) as its typed value
+	//
+	// for vars matched against a var type (e.g. `{id:int}`) returns the converted value - otherwise returns the var value
+	GetTyped(idents ...interface{}) (interface{}, bool)
+	// GetInt gets the path var (by position, name or name and position - same as Get) as an int
+	GetInt(idents ...interface{}) (int, error)
+	// GetInt64 gets the path var (by position, name or name and position - same as Get) as an int64
+	GetInt64(idents ...interface{}) (int64, error)
+	// GetBool gets the path var (by position, name or name and position - same as Get) as a bool
+	GetBool(idents ...interface{}) (bool, error)
+	// GetFloat gets the path var (by position, name or name and position - same as Get) as a float64
+	GetFloat(idents ...interface{}) (float64, error)
+	// GetTime gets the path var (by position or name) as a time.Time - parsed using the layout (if the layout is empty, the typed value of a typed var such as `{d:date}` is used - or the value is parsed as time.RFC3339)
+	GetTime(ident interface{}, layout string) (time.Time, error)
 	GetAll() []PathVar
 	Len() int
 	Clear()
@@ -25,6 +51,8 @@ type PathVars interface {
 	VarsType() PathVarsType
 	AddNamedValue(name string, val interface{}) error
 	AddPositionalValue(val interface{}) error
+	// Bind binds the path vars into the fields of the destination struct (see package func Bind)
+	Bind(dst interface{}) error
 }
 
 type pathVars struct {
@@ -111,6 +139,63 @@ func (pvs *pathVars) Get(idents ...interface{}) (string, bool) {
 	return "", false
 }
 
+// GetSegments gets the path var (by position, name or name and position - same as Get) as Segments
+//
+// for a catch-all var, returns the captured path segments - for any other var, returns the single value as Segments
+func (pvs *pathVars) GetSegments(idents ...interface{}) (Segments, bool) {
+	if v, ok := pvs.getVar(idents); ok {
+		switch av := v.Value.(type) {
+		case Segments:
+			return av, true
+		case []string:
+			return av, true
+		default:
+			if str, ok := getValueIf(av); ok {
+				return Segments{str}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// GetTyped gets the path var (by position, name or name and position - same as Get) as its typed value
+//
+// for vars matched against a var type (e.g. `{id:int}`) returns the converted value - otherwise returns the var value
+func (pvs *pathVars) GetTyped(idents ...interface{}) (interface{}, bool) {
+	if v, ok := pvs.getVar(idents); ok {
+		if v.TypedValue != nil {
+			return v.TypedValue, true
+		}
+		return v.Value, true
+	}
+	return nil, false
+}
+
+func (pvs *pathVars) getVar(idents []interface{}) (PathVar, bool) {
+	if len(idents) < 1 || len(idents) > 2 {
+		return PathVar{}, false
+	}
+	var vs []PathVar
+	position := 0
+	if pos, ok := idents[0].(int); ok && len(idents) == 1 {
+		vs, position = pvs.all, pos
+	} else if name, ok := idents[0].(string); ok {
+		vs = pvs.named[name]
+		if len(idents) > 1 {
+			if position, ok = idents[1].(int); !ok {
+				return PathVar{}, false
+			}
+		}
+	}
+	if position < 0 {
+		position = len(vs) + position
+	}
+	if position >= 0 && position < len(vs) {
+		return vs[position], true
+	}
+	return PathVar{}, false
+}
+
 func (pvs *pathVars) GetAll() []PathVar {
 	return pvs.all
 }
@@ -156,6 +241,11 @@ func (pvs *pathVars) AddPositionalValue(val interface{}) error {
 	return nil
 }
 
+// Bind binds the path vars into the fields of the destination struct (see package func Bind)
+func (pvs *pathVars) Bind(dst interface{}) error {
+	return Bind(pvs, dst)
+}
+
 // Positional creates a positional PathVars from the values supplied
 func Positional(values ...interface{}) PathVars {
 	result := newPathVars(Positions)
@@ -0,0 +1,101 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrPathVarNotFound is the error (wrapped) returned by the typed path var getters when the path var is not present
+var ErrPathVarNotFound = errors.New("path var not found")
+
+// GetAs gets the path var (by position, name or name and position - same as PathVars.Get) converted to the type T
+//
+// T can be any type supported by Bind (e.g. string, int, uint, float, bool, time.Duration, time.Time or any type
+// implementing encoding.TextUnmarshaler) - for vars matched against a var type (e.g. `{id:int}`), the typed value is
+// used where it is assignable to T
+//
+// If the value cannot be converted, a VarConversionError is returned - if the path var is not present, the
+// error returned wraps ErrPathVarNotFound
+func GetAs[T any](vars PathVars, idents ...interface{}) (T, error) {
+	var result T
+	err := getAs(vars, reflect.ValueOf(&result).Elem(), "", idents)
+	return result, err
+}
+
+func getAs(vars PathVars, rv reflect.Value, layout string, idents []interface{}) error {
+	var v PathVar
+	ok := false
+	if pvs, isPvs := vars.(*pathVars); isPvs {
+		v, ok = pvs.getVar(idents)
+	} else if vars != nil && len(idents) > 0 {
+		var str string
+		if str, ok = vars.Get(idents...); ok {
+			v = PathVar{Value: str}
+			v.Name, _ = idents[0].(string)
+			v.Position, _ = idents[0].(int)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPathVarNotFound, identString(idents))
+	}
+	if v.TypedValue != nil && layout == "" {
+		if tv := reflect.ValueOf(v.TypedValue); tv.Type().AssignableTo(rv.Type()) {
+			rv.Set(tv)
+			return nil
+		}
+	}
+	str, err := getValue(v.Value)
+	if err == nil {
+		err = convertVar(str, rv, layout)
+	}
+	if err != nil {
+		return newVarConversionError(identName(v), str, "", err)
+	}
+	return nil
+}
+
+func identString(idents []interface{}) string {
+	if len(idents) == 2 {
+		return fmt.Sprintf("'%v' (position %v)", idents[0], idents[1])
+	} else if len(idents) == 1 {
+		return fmt.Sprintf("'%v'", idents[0])
+	}
+	return fmt.Sprintf("%v", idents)
+}
+
+func identName(v PathVar) string {
+	if v.Name == "" {
+		return fmt.Sprintf("%d", v.Position)
+	}
+	return v.Name
+}
+
+// GetInt gets the path var (by position, name or name and position - same as Get) as an int
+func (pvs *pathVars) GetInt(idents ...interface{}) (int, error) {
+	return GetAs[int](pvs, idents...)
+}
+
+// GetInt64 gets the path var (by position, name or name and position - same as Get) as an int64
+func (pvs *pathVars) GetInt64(idents ...interface{}) (int64, error) {
+	return GetAs[int64](pvs, idents...)
+}
+
+// GetBool gets the path var (by position, name or name and position - same as Get) as a bool
+func (pvs *pathVars) GetBool(idents ...interface{}) (bool, error) {
+	return GetAs[bool](pvs, idents...)
+}
+
+// GetFloat gets the path var (by position, name or name and position - same as Get) as a float64
+func (pvs *pathVars) GetFloat(idents ...interface{}) (float64, error) {
+	return GetAs[float64](pvs, idents...)
+}
+
+// GetTime gets the path var (by position or name) as a time.Time - parsed using the layout (if the layout is empty, the typed value of a typed var such as `{d:date}` is used - or the value is parsed as time.RFC3339)
+func (pvs *pathVars) GetTime(ident interface{}, layout string) (time.Time, error) {
+	var result time.Time
+	// an empty layout uses any typed value (e.g. from a `{d:date}` var) - otherwise the value is parsed as RFC3339...
+	err := getAs(pvs, reflect.ValueOf(&result).Elem(), layout, []interface{}{ident})
+	return result, err
+}
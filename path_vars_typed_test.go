@@ -0,0 +1,147 @@
+package urit
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPathVars_GetInt(t *testing.T) {
+	vars := Named("id", "123", "id", "x", "big", "99999999999999999999")
+	i, err := vars.GetInt("id")
+	require.NoError(t, err)
+	require.Equal(t, 123, i)
+	_, err = vars.GetInt("id", 1)
+	require.Error(t, err)
+	require.Equal(t, `cannot convert path var 'id' value 'x': strconv.ParseInt: parsing "x": invalid syntax`, err.Error())
+	cerr, ok := err.(VarConversionError)
+	require.True(t, ok)
+	require.Equal(t, "id", cerr.Name())
+	require.Equal(t, "x", cerr.Value())
+	require.True(t, errors.Is(err, strconv.ErrSyntax))
+	_, err = vars.GetInt("big")
+	require.Error(t, err)
+	i64, err := vars.GetInt64(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(123), i64)
+
+	_, err = vars.GetInt("missing")
+	require.Error(t, err)
+	require.Equal(t, `path var not found: 'missing'`, err.Error())
+	require.True(t, errors.Is(err, ErrPathVarNotFound))
+	_, err = vars.GetInt("id", 2)
+	require.Error(t, err)
+	require.Equal(t, `path var not found: 'id' (position 2)`, err.Error())
+}
+
+func TestPathVars_GetBoolFloat(t *testing.T) {
+	vars := Positional("true", "1.5", "x")
+	b, err := vars.GetBool(0)
+	require.NoError(t, err)
+	require.True(t, b)
+	f, err := vars.GetFloat(1)
+	require.NoError(t, err)
+	require.Equal(t, 1.5, f)
+	_, err = vars.GetBool(2)
+	require.Error(t, err)
+	require.Equal(t, `cannot convert path var '2' value 'x': strconv.ParseBool: parsing "x": invalid syntax`, err.Error())
+	_, err = vars.GetFloat(2)
+	require.Error(t, err)
+}
+
+func TestPathVars_GetTime(t *testing.T) {
+	vars := Named("d", "2022-11-30", "ts", "2022-11-30T10:11:12Z")
+	tm, err := vars.GetTime("d", "2006-01-02")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC), tm)
+	tm, err = vars.GetTime("ts", "")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 11, 30, 10, 11, 12, 0, time.UTC), tm)
+	_, err = vars.GetTime("d", "")
+	require.Error(t, err)
+	_, err = vars.GetTime("missing", "")
+	require.True(t, errors.Is(err, ErrPathVarNotFound))
+}
+
+func TestPathVars_GetTime_Typed(t *testing.T) {
+	vars, ok := MustCreateTemplate(`/credits/{d:date}`).Matches(`/credits/2022-11-30`)
+	require.True(t, ok)
+	tm, err := vars.GetTime("d", "")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC), tm)
+	tm2, err := GetAs[time.Time](vars, "d")
+	require.NoError(t, err)
+	require.Equal(t, tm, tm2)
+	// explicit layout parses the raw value...
+	tm, err = vars.GetTime("d", "2006-01-02")
+	require.NoError(t, err)
+	require.Equal(t, tm2, tm)
+	_, err = vars.GetTime("d", time.RFC3339)
+	require.Error(t, err)
+}
+
+type testUpper string
+
+func (u *testUpper) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty")
+	}
+	*u = testUpper("UP-" + string(text))
+	return nil
+}
+
+func TestGetAs(t *testing.T) {
+	vars, ok := MustCreateTemplate(`/orders/{id:int}/{d:date}/{code}/{ttl}`).Matches(`/orders/12/2022-11-30/abc/5s`)
+	require.True(t, ok)
+	i, err := GetAs[int](vars, "id")
+	require.NoError(t, err)
+	require.Equal(t, 12, i)
+	u8, err := GetAs[uint8](vars, "id")
+	require.NoError(t, err)
+	require.Equal(t, uint8(12), u8)
+	tm, err := GetAs[time.Time](vars, "d")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC), tm)
+	s, err := GetAs[string](vars, "code")
+	require.NoError(t, err)
+	require.Equal(t, "abc", s)
+	up, err := GetAs[testUpper](vars, "code")
+	require.NoError(t, err)
+	require.Equal(t, testUpper("UP-abc"), up)
+	pi, err := GetAs[*int](vars, 0)
+	require.NoError(t, err)
+	require.Equal(t, 12, *pi)
+	d, err := GetAs[time.Duration](vars, "ttl")
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, d)
+	_, err = GetAs[int](vars, "code")
+	require.Error(t, err)
+	_, err = GetAs[int](vars, "missing")
+	require.True(t, errors.Is(err, ErrPathVarNotFound))
+	_, err = GetAs[int](nil, "missing")
+	require.True(t, errors.Is(err, ErrPathVarNotFound))
+}
+
+// wrappedPathVars wraps a PathVars (so is not the urit implementation)
+type wrappedPathVars struct {
+	PathVars
+}
+
+func TestGetAs_WrappedPathVars(t *testing.T) {
+	vars := wrappedPathVars{Named("id", "12", "ok", "true")}
+	i, err := GetAs[int](vars, "id")
+	require.NoError(t, err)
+	require.Equal(t, 12, i)
+	b, err := GetAs[bool](vars, "ok")
+	require.NoError(t, err)
+	require.True(t, b)
+	_, err = GetAs[int](vars, "missing")
+	require.True(t, errors.Is(err, ErrPathVarNotFound))
+	dst := struct {
+		Id int `urit:"id"`
+	}{}
+	require.NoError(t, Bind(vars, &dst))
+	require.Equal(t, 12, dst.Id)
+}
@@ -0,0 +1,124 @@
+package urit
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MatchesPrefix checks whether the start of the specified path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// where the template has optional groups, the longest matching prefix is used
+func (t *template) MatchesPrefix(path string, options ...interface{}) (PathVars, string, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, "", false
+	}
+	return t.matchesPrefix(u, options)
+}
+
+// MatchesPrefixUrl checks whether the start of the specified URL path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// where the template has optional groups, the longest matching prefix is used
+func (t *template) MatchesPrefixUrl(u url.URL, options ...interface{}) (PathVars, string, bool) {
+	return t.matchesPrefix(&u, options)
+}
+
+// MatchesPrefixRequest checks whether the start of the specified request path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// where the template has optional groups, the longest matching prefix is used
+func (t *template) MatchesPrefixRequest(req *http.Request, options ...interface{}) (PathVars, string, bool) {
+	return t.matchesPrefix(requestUrl(req), options)
+}
+
+func (t *template) matchesPrefix(u *url.URL, options []interface{}) (PathVars, string, bool) {
+	escaped, ok := t.matchPath(u, options)
+	if !ok {
+		return nil, "", false
+	}
+	rawPts, ok := splitPathPolicy(escaped, t.slashPolicyFor(options))
+	if !ok {
+		return nil, "", false
+	}
+	// decoding is done in place - so keep the raw segments for the remainder...
+	pts, ok := decodeSegments(append(make([]string, 0, len(rawPts)), rawPts...), options)
+	if !ok {
+		return nil, "", false
+	}
+	vars, n, ok := t.matchPrefix(u, pts, options)
+	if !ok {
+		return nil, "", false
+	}
+	remainder := "/" + strings.Join(rawPts[n:], "/")
+	if n < len(rawPts) && strings.HasSuffix(escaped, "/") {
+		remainder += "/"
+	}
+	return vars, remainder, true
+}
+
+// matchPrefix matches the template against the leading path segments (longest first) - returning the vars and the
+// number of path segments matched
+func (t *template) matchPrefix(u *url.URL, pts []string, options []interface{}) (PathVars, int, bool) {
+	limit := len(pts)
+	if !t.hasCatchAll() && len(t.pathParts) < limit {
+		limit = len(t.pathParts)
+	}
+	for n := limit; n >= 0; n-- {
+		if t.allowsSegmentCount(n) {
+			if vars, ok := t.matchSegments(u, pts[:n], options); ok {
+				return vars, n, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// MatchesPrefix checks whether the start of the specified path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// the longest matching prefix (ending at a "/") is used
+func (t *rfc6570Template) MatchesPrefix(path string, options ...interface{}) (PathVars, string, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, "", false
+	}
+	return t.MatchesPrefixUrl(*u, options...)
+}
+
+// MatchesPrefixUrl checks whether the start of the specified URL path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// the longest matching prefix (ending at a "/") is used
+func (t *rfc6570Template) MatchesPrefixUrl(u url.URL, options ...interface{}) (PathVars, string, bool) {
+	escaped, ok := normalizedPath(u.EscapedPath(), nil, options)
+	if !ok {
+		return nil, "", false
+	}
+	base := ""
+	if t.absolute && u.Host != "" {
+		base = u.Scheme + "://" + u.Host
+	}
+	for end := len(escaped); end >= 0; end = strings.LastIndexByte(escaped[:end], '/') {
+		if vars, ok := t.matches(base+escaped[:end], u.RawQuery, options); ok {
+			remainder := escaped[end:]
+			if !strings.HasPrefix(remainder, "/") {
+				remainder = "/" + remainder
+			}
+			return vars, remainder, true
+		} else if end == 0 {
+			break
+		}
+	}
+	return nil, "", false
+}
+
+// MatchesPrefixRequest checks whether the start of the specified request path matches the template -
+// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+//
+// the longest matching prefix (ending at a "/") is used
+func (t *rfc6570Template) MatchesPrefixRequest(req *http.Request, options ...interface{}) (PathVars, string, bool) {
+	return t.MatchesPrefixUrl(*requestUrl(req), options...)
+}
@@ -0,0 +1,123 @@
+package urit
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestTemplate_MatchesPrefix(t *testing.T) {
+	tmp := MustCreateTemplate(`/tenants/{t}`)
+	testCases := []struct {
+		path            string
+		expectOk        bool
+		expectRemainder string
+		expectTenant    string
+	}{
+		{`/tenants/acme/orders/5`, true, `/orders/5`, "acme"},
+		{`/tenants/acme/orders/5/`, true, `/orders/5/`, "acme"},
+		{`/tenants/acme`, true, `/`, "acme"},
+		{`/tenants/acme/`, true, `/`, "acme"},
+		{`/tenants/a%20b/x%2Fy?q=1`, true, `/x%2Fy`, "a b"},
+		{`/tenants`, false, ``, ""},
+		{`/other/acme/orders`, false, ``, ""},
+		{`/tenants//orders`, false, ``, ""},
+		{"/tenants/\x7f", false, ``, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			vars, remainder, ok := tmp.MatchesPrefix(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			require.Equal(t, tc.expectRemainder, remainder)
+			if ok {
+				v, _ := vars.Get("t")
+				require.Equal(t, tc.expectTenant, v)
+			}
+		})
+	}
+
+	u, _ := url.Parse(`/tenants/acme/orders/5`)
+	vars, remainder, ok := tmp.MatchesPrefixUrl(*u)
+	require.True(t, ok)
+	require.Equal(t, `/orders/5`, remainder)
+	require.Equal(t, 1, vars.Len())
+
+	req, _ := http.NewRequest(http.MethodGet, `/tenants/acme/orders/5`, nil)
+	vars, remainder, ok = tmp.MatchesPrefixRequest(req)
+	require.True(t, ok)
+	require.Equal(t, `/orders/5`, remainder)
+	require.Equal(t, 1, vars.Len())
+
+	// an exact match is a prefix match...
+	tmp = MustCreateTemplate(`/`)
+	_, remainder, ok = tmp.MatchesPrefix(`/orders/5`)
+	require.True(t, ok)
+	require.Equal(t, `/orders/5`, remainder)
+}
+
+func TestTemplate_MatchesPrefix_Host(t *testing.T) {
+	tmp := MustCreateTemplate(`https://{tenant}.example.com/api`)
+	req, _ := http.NewRequest(http.MethodGet, `/api/orders/5`, nil)
+	req.Host = "acme.example.com"
+	req.TLS = &tls.ConnectionState{}
+	vars, remainder, ok := tmp.MatchesPrefixRequest(req)
+	require.True(t, ok)
+	require.Equal(t, `/orders/5`, remainder)
+	v, _ := vars.Get("tenant")
+	require.Equal(t, "acme", v)
+
+	_, _, ok = tmp.MatchesPrefix(`/api/orders/5`)
+	require.False(t, ok)
+}
+
+func TestTemplate_MatchesPrefix_OptionalGroupsAndCatchAll(t *testing.T) {
+	tmp := MustCreateTemplate(`/tenants/{t}[/{region:[a-z]{2}}]`)
+	vars, remainder, ok := tmp.MatchesPrefix(`/tenants/acme/eu/orders`)
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	require.Equal(t, 2, vars.Len())
+	vars, remainder, ok = tmp.MatchesPrefix(`/tenants/acme/orders`)
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	require.Equal(t, 1, vars.Len())
+
+	tmp = MustCreateTemplate(`/files/{path...}`)
+	vars, remainder, ok = tmp.MatchesPrefix(`/files/a/b`)
+	require.True(t, ok)
+	require.Equal(t, `/`, remainder)
+	p, _ := vars.GetSegments("path")
+	require.Equal(t, Segments{"a", "b"}, p)
+}
+
+func TestRfc6570Template_MatchesPrefix(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/tenants{/t}`)
+	vars, remainder, ok := tmp.MatchesPrefix(`/tenants/acme/orders/5`)
+	require.True(t, ok)
+	require.Equal(t, `/orders/5`, remainder)
+	v, _ := vars.Get("t")
+	require.Equal(t, "acme", v)
+
+	_, remainder, ok = tmp.MatchesPrefix(`/tenants/acme`)
+	require.True(t, ok)
+	require.Equal(t, `/`, remainder)
+
+	req, _ := http.NewRequest(http.MethodGet, `/tenants/acme/orders/`, nil)
+	_, remainder, ok = tmp.MatchesPrefixRequest(req)
+	require.True(t, ok)
+	require.Equal(t, `/orders/`, remainder)
+
+	_, _, ok = tmp.MatchesPrefix(`/other/acme`)
+	require.False(t, ok)
+	_, _, ok = tmp.MatchesPrefix("/\x7f")
+	require.False(t, ok)
+
+	tmp = MustCreateRfc6570Template(`https://{tenant}.example.com/api`)
+	u, _ := url.Parse(`https://acme.example.com/api/orders`)
+	vars, remainder, ok = tmp.MatchesPrefixUrl(*u)
+	require.True(t, ok)
+	require.Equal(t, `/orders`, remainder)
+	v, _ = vars.Get("tenant")
+	require.Equal(t, "acme", v)
+}
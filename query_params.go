@@ -128,7 +128,7 @@ func (qp *queryParams) Clone() QueryParams {
 		sorted: qp.sorted,
 	}
 	for k, v := range qp.params {
-		result.params[k] = append(v)
+		result.params[k] = append([]interface{}{}, v...)
 	}
 	return result
 }
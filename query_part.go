@@ -0,0 +1,253 @@
+package urit
+
+import (
+	"fmt"
+	"github.com/go-andiamo/splitter"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// queryPart is a query param in a template query section - e.g. the `q` and `page` in `/search{?q,page:[0-9]+}`
+//
+// query params are optional unless suffixed with '!' (e.g. `{?q!}`) and may only have a single value
+// unless suffixed with '*' (e.g. `{?tag*}`)
+type queryPart struct {
+	name      string
+	regexp    *regexp.Regexp
+	orgRegexp string
+	required  bool
+	repeated  bool
+	typedVar
+}
+
+var querySplitter = splitter.MustCreateSplitter(',',
+	splitter.MustMakeEscapable(splitter.Parenthesis, '\\'),
+	splitter.MustMakeEscapable(splitter.CurlyBrackets, '\\'),
+	splitter.MustMakeEscapable(splitter.SquareBrackets, '\\')).
+	AddDefaultOptions(splitter.TrimSpaces, splitter.NoEmptiesMsg("query params cannot be empty"))
+
+// splitQuerySection splits any query section (e.g. `{?q,page:[0-9]+}`) from the end of the template
+//
+// returns the template path, the query section contents and the position of the query section (-1 if no query section)
+func splitQuerySection(s string) (string, string, int, error) {
+	depth := 0
+	var quote byte
+	start := -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' && depth == 0 && i+1 < len(s) && s[i+1] == '?':
+			start = i
+			depth++
+		case c == '{' || c == '(' || c == '[':
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth == 0 && start != -1 {
+				if i != len(s)-1 {
+					return "", "", start, newTemplateParseError(fmt.Sprintf("query section must be at end of template (position %d)", start), start, nil)
+				}
+				return s[:start], s[start+2 : i], start, nil
+			}
+		}
+	}
+	if start != -1 {
+		return "", "", start, newTemplateParseError(fmt.Sprintf("unclosed query section at position %d", start), start, nil)
+	}
+	return s, "", -1, nil
+}
+
+// parseQueryParts parses the query section contents into query parts
+func parseQueryParts(section string, pos int) ([]queryPart, error) {
+	strs, err := querySplitter.Split(section)
+	if err != nil {
+		return nil, newTemplateParseError("query section problem", pos, err)
+	}
+	result := make([]queryPart, 0, len(strs))
+	seen := map[string]bool{}
+	for _, str := range strs {
+		qp := queryPart{}
+		name := str
+		if cAt := strings.IndexByte(str, ':'); cAt != -1 {
+			name = strings.Trim(str[:cAt], " ")
+			qp.orgRegexp = strings.Trim(str[cAt+1:], " ")
+			if qp.orgRegexp != "" {
+				vt, args, rxs, err := parseVarType(qp.orgRegexp)
+				if err != nil {
+					return nil, newTemplateParseError("query param type problem", pos, err)
+				}
+				qp.varType, qp.varTypeArgs = vt, args
+				rx, err := regexp.Compile(addRegexHeadAndTail(rxs))
+				if err != nil {
+					return nil, newTemplateParseError("query param regexp problem", pos, err)
+				}
+				qp.regexp = rx
+			}
+		}
+		for len(name) > 0 && (name[len(name)-1] == '!' || name[len(name)-1] == '*') {
+			if name[len(name)-1] == '!' {
+				qp.required = true
+			} else {
+				qp.repeated = true
+			}
+			name = name[:len(name)-1]
+		}
+		qp.name = strings.Trim(name, " ")
+		if qp.name == "" {
+			return nil, newTemplateParseError("query param name cannot be empty", pos, nil)
+		} else if seen[qp.name] {
+			return nil, newTemplateParseError(fmt.Sprintf("duplicate query param '%s'", qp.name), pos, nil)
+		}
+		seen[qp.name] = true
+		result = append(result, qp)
+	}
+	return result, nil
+}
+
+// match matches the query param values (from the request query) - adding any found values to the vars
+func (qp *queryPart) match(values []string, vars PathVars, vOpts varMatchOptions) bool {
+	if len(values) == 0 {
+		return !qp.required
+	} else if len(values) > 1 && !qp.repeated {
+		return false
+	}
+	for _, s := range values {
+		ok := qp.regexp == nil || qp.regexp.MatchString(s)
+		if len(vOpts) > 0 {
+			if rs, vok, applicable := vOpts.check(s, vars.Len(), qp.name, qp.regexp, qp.orgRegexp, -1, vars); applicable {
+				s = rs
+				ok = vok
+			}
+		}
+		var tv interface{}
+		if ok {
+			tv, ok = qp.convertTyped(s)
+		}
+		if !ok {
+			return false
+		}
+		_ = vars.AddNamedValue(qp.name, s)
+		setLastTypedValue(vars, tv)
+	}
+	return true
+}
+
+// queryFrom writes the query param(s) from the vars (or, if not in the vars, from the query params option)
+func (qp *queryPart) queryFrom(qb *strings.Builder, tracker *positionsTracker, params QueryParams) error {
+	values := make([]string, 0)
+	if tracker.vars != nil && tracker.vars.VarsType() == Names {
+		for np := tracker.namedPositions[qp.name]; ; np++ {
+			if str, ok := tracker.vars.GetNamed(qp.name, np); ok {
+				values = append(values, str)
+			} else {
+				break
+			}
+		}
+	}
+	if len(values) == 0 && params != nil {
+		for i := 0; ; i++ {
+			if v, ok := params.GetIndex(qp.name, i); ok {
+				str, err := getValue(v)
+				if err != nil {
+					return err
+				}
+				values = append(values, str)
+			} else {
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		if qp.required {
+			return fmt.Errorf("no var for query param '%s'", qp.name)
+		}
+		return nil
+	} else if !qp.repeated {
+		values = values[:1]
+	}
+	for _, str := range values {
+		for _, ck := range tracker.varMatches {
+			if ck.Applicable(str, -1, qp.name, qp.regexp, qp.orgRegexp, -1, tracker.vars) {
+				if altS, ok := ck.Match(str, -1, qp.name, qp.regexp, qp.orgRegexp, -1, tracker.vars); ok {
+					str = altS
+				} else {
+					return fmt.Errorf("no match query param '%s'", qp.name)
+				}
+			}
+		}
+		qb.WriteString(ampersandOrQuestionMark(qb.Len() == 0))
+		qb.WriteString(url.QueryEscape(qp.name))
+		qb.WriteString("=")
+		qb.WriteString(queryEncode(str, tracker.encoding))
+	}
+	return nil
+}
+
+func queryEncode(s string, encoding EncodingOption) string {
+	if encoding == nil || encoding == _PercentEncoded {
+		return url.QueryEscape(s)
+	}
+	return encoding.Encode(s)
+}
+
+// parseRawQuery parses the raw query (decoding names and values according to the encoding option)
+func parseRawQuery(rawQuery string, encoding EncodingOption) (map[string][]string, bool) {
+	result := map[string][]string{}
+	for _, pr := range strings.Split(rawQuery, "&") {
+		if pr == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pr, "=")
+		if encoding == nil || encoding == _PercentEncoded {
+			var err1, err2 error
+			k, err1 = url.QueryUnescape(k)
+			v, err2 = url.QueryUnescape(v)
+			if err1 != nil || err2 != nil {
+				return nil, false
+			}
+		} else {
+			var ok bool
+			if v, ok = encoding.Decode(v); !ok {
+				return nil, false
+			}
+		}
+		result[k] = append(result[k], v)
+	}
+	return result, true
+}
+
+func (qp *queryPart) String(removePattern bool) string {
+	var sb strings.Builder
+	sb.WriteString(qp.name)
+	if qp.required {
+		sb.WriteString("!")
+	}
+	if qp.repeated {
+		sb.WriteString("*")
+	}
+	if !removePattern && qp.orgRegexp != "" {
+		sb.WriteString(":" + qp.orgRegexp)
+	}
+	return sb.String()
+}
+
+// querySection builds the query section string (e.g. `{?q,page:[0-9]+}`) from the query parts
+func querySection(qps []queryPart, removePatterns bool) string {
+	if len(qps) == 0 {
+		return ""
+	}
+	strs := make([]string, 0, len(qps))
+	for _, qp := range qps {
+		strs = append(strs, qp.String(removePatterns))
+	}
+	return "{?" + strings.Join(strs, ",") + "}"
+}
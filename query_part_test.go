@@ -0,0 +1,279 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestTemplate_QuerySection_Parse(t *testing.T) {
+	tmp, err := NewTemplate(`/search{?q!, tag*, page:[0-9]{1,3}}`)
+	require.NoError(t, err)
+	require.Equal(t, Names, tmp.VarsType())
+	require.Equal(t, `/search{?q!, tag*, page:[0-9]{1,3}}`, tmp.OriginalTemplate())
+	require.Equal(t, `/search{?q!,tag*,page}`, tmp.Template(true))
+	rt := tmp.(*template)
+	require.Equal(t, 1, len(rt.pathParts))
+	require.Equal(t, 3, len(rt.queryParts))
+	require.True(t, rt.queryParts[0].required)
+	require.True(t, rt.queryParts[1].repeated)
+	require.Equal(t, `[0-9]{1,3}`, rt.queryParts[2].orgRegexp)
+	require.Equal(t, []PathVar{
+		{Name: "q", Position: 0},
+		{Name: "tag", Position: 1, Optional: true},
+		{Name: "page", Position: 2, Optional: true},
+	}, tmp.Vars())
+
+	tmp, err = NewTemplate(`/users/{id}/{?fields}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(tmp.(*template).pathParts))
+	require.Equal(t, 2, len(tmp.Vars()))
+
+	tmp, err = NewTemplate(`{?q}`)
+	require.NoError(t, err)
+	require.Equal(t, `/{?q}`, tmp.OriginalTemplate())
+	require.Equal(t, 0, len(tmp.(*template).pathParts))
+}
+
+func TestTemplate_QuerySection_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template  string
+		expectErr string
+	}{
+		{`/search{?q}/more`, `query section must be at end of template (position 7)`},
+		{`/search{?q`, `unclosed query section at position 7`},
+		{`/search{?q,,page}`, `query section problem`},
+		{`/search{?q,q}`, `duplicate query param 'q'`},
+		{`/search{?:[0-9]+}`, `query param name cannot be empty`},
+		{`/search{?!}`, `query param name cannot be empty`},
+		{`/search{?q:a**}`, `query param regexp problem`},
+		{`/search/?{?q}`, `template cannot contain both positional and named path variables`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewTemplate(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+			_, ok := err.(TemplateParseError)
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestTemplate_QuerySection_Matches(t *testing.T) {
+	testCases := []struct {
+		template   string
+		path       string
+		options    []interface{}
+		expectOk   bool
+		expectVars map[string][]string
+	}{
+		{
+			template:   `/search{?q,page:[0-9]+}`,
+			path:       `/search?q=go+lang&page=2&other=x`,
+			expectOk:   true,
+			expectVars: map[string][]string{"q": {"go lang"}, "page": {"2"}},
+		},
+		{
+			template:   `/search{?q,page:[0-9]+}`,
+			path:       `/search`,
+			expectOk:   true,
+			expectVars: map[string][]string{},
+		},
+		{
+			template: `/search{?q,page:[0-9]+}`,
+			path:     `/search?page=x`,
+		},
+		{
+			template: `/search{?q,page:[0-9]+}`,
+			path:     `/search?page=1&page=2`,
+		},
+		{
+			template: `/search{?q!,page:[0-9]+}`,
+			path:     `/search?page=1`,
+		},
+		{
+			template:   `/search{?q!,page:[0-9]+}`,
+			path:       `/search?q=&page=1`,
+			expectOk:   true,
+			expectVars: map[string][]string{"q": {""}, "page": {"1"}},
+		},
+		{
+			template:   `/search{?tag*:[a-z]+}`,
+			path:       `/search?tag=a&tag=b`,
+			expectOk:   true,
+			expectVars: map[string][]string{"tag": {"a", "b"}},
+		},
+		{
+			template: `/search{?tag*:[a-z]+}`,
+			path:     `/search?tag=a&tag=1`,
+		},
+		{
+			template: `/search{?q}`,
+			path:     `/search?q=%zz`,
+		},
+		{
+			template:   `/search{?q}`,
+			path:       `/search?q=a%2Bb`,
+			options:    []interface{}{PreEncoded},
+			expectOk:   true,
+			expectVars: map[string][]string{"q": {"a%2Bb"}},
+		},
+		{
+			template: `/search{?q}`,
+			path:     `/search?q=a`,
+			options:  []interface{}{&rejectingVar{}},
+		},
+		{
+			template:   `/users/{id}{?fields*}`,
+			path:       `https://www.example.com/users/123?fields=a&fields=b`,
+			expectOk:   true,
+			expectVars: map[string][]string{"id": {"123"}, "fields": {"a", "b"}},
+		},
+		{
+			template: `/users/{id}{?fields*}`,
+			path:     `/users?fields=a`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path, tc.options...)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				count := 0
+				for k, vs := range tc.expectVars {
+					for i, v := range vs {
+						av, ok := vars.GetNamed(k, i)
+						require.True(t, ok)
+						require.Equal(t, v, av)
+						count++
+					}
+				}
+				require.Equal(t, count, vars.Len())
+			} else {
+				require.Nil(t, vars)
+			}
+		})
+	}
+}
+
+func TestTemplate_QuerySection_MatchesRequest(t *testing.T) {
+	tmp := MustCreateTemplate(`/search{?q!}`)
+	req, err := http.NewRequest(http.MethodGet, `https://www.example.com/search?q=go`, nil)
+	require.NoError(t, err)
+	vars, ok := tmp.MatchesRequest(req)
+	require.True(t, ok)
+	v, _ := vars.Get("q")
+	require.Equal(t, "go", v)
+	vars, ok = tmp.MatchesUrl(*req.URL)
+	require.True(t, ok)
+	v, _ = vars.Get("q")
+	require.Equal(t, "go", v)
+
+	req, err = http.NewRequest(http.MethodGet, `https://www.example.com/search?tag=a`, nil)
+	require.NoError(t, err)
+	vars, ok = tmp.MatchesRequest(req)
+	require.False(t, ok)
+	require.Nil(t, vars)
+}
+
+func TestTemplate_QuerySection_PathFrom(t *testing.T) {
+	tmp := MustCreateTemplate(`/search{?q!,tag*,page:[0-9]+}`)
+	pth, err := tmp.PathFrom(Named("q", "go lang", "tag", "a", "tag", "b", "page", 2))
+	require.NoError(t, err)
+	require.Equal(t, `/search?q=go+lang&tag=a&tag=b&page=2`, pth)
+
+	pth, err = tmp.PathFrom(Named("q", "go", "page", 2, "page", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/search?q=go&page=2`, pth)
+
+	_, err = tmp.PathFrom(Named("page", 2))
+	require.Error(t, err)
+	require.Equal(t, `no var for query param 'q'`, err.Error())
+
+	q, err := NewQueryParams("q", "from-params", "tag", "c", "extra", "x")
+	require.NoError(t, err)
+	pth, err = tmp.PathFrom(Named("tag", "a"), q)
+	require.NoError(t, err)
+	require.Equal(t, `/search?q=from-params&tag=a&extra=x`, pth)
+	require.True(t, q.Has("q"))
+
+	_, err = tmp.PathFrom(nil, q)
+	require.NoError(t, err)
+	_, err = tmp.PathFrom(Named("q", func() {}))
+	require.Error(t, err)
+	q, _ = NewQueryParams("q", func() {})
+	_, err = tmp.PathFrom(nil, q)
+	require.Error(t, err)
+
+	pth, err = tmp.PathFrom(Named("q", "a%2Bb"), PreEncoded)
+	require.NoError(t, err)
+	require.Equal(t, `/search?q=a%2Bb`, pth)
+
+	_, err = tmp.PathFrom(Named("q", "x"), &rejectingVar{})
+	require.Error(t, err)
+	require.Equal(t, `no match query param 'q'`, err.Error())
+
+	tmp = MustCreateTemplate(`/{?q}`)
+	pth, err = tmp.PathFrom(Named("q", "x"))
+	require.NoError(t, err)
+	require.Equal(t, `/?q=x`, pth)
+
+	tmp = MustCreateTemplate(`/{id}/{id}{?id}`)
+	pth, err = tmp.PathFrom(Named("id", 1, "id", 2, "id", 3))
+	require.NoError(t, err)
+	require.Equal(t, `/1/2?id=3`, pth)
+}
+
+func TestTemplate_QuerySection_PathFromStruct(t *testing.T) {
+	tmp := MustCreateTemplate(`/search/{category}{?q!,page}`)
+	pth, err := tmp.PathFromStruct(struct {
+		Category string `urit:"category"`
+		Query    string `query:"q"`
+		Sort     string `query:"sort"`
+	}{Category: "books", Query: "go", Sort: "asc"})
+	require.NoError(t, err)
+	require.Equal(t, `/search/books?q=go&sort=asc`, pth)
+
+	_, err = tmp.PathFromStruct(struct{}{})
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: 'category', 'q'`, err.Error())
+}
+
+func TestTemplate_QuerySection_SubAndResolveTo(t *testing.T) {
+	tmp := MustCreateTemplate(`/users/{id}{?fields}`)
+	tmp2, err := tmp.Sub(`/orders/{order-id}{?status}`)
+	require.NoError(t, err)
+	require.Equal(t, `/users/{id}/orders/{order-id}{?fields,status}`, tmp2.OriginalTemplate())
+	vars, ok := tmp2.Matches(`/users/1/orders/2?status=open`)
+	require.True(t, ok)
+	require.Equal(t, 3, vars.Len())
+
+	_, err = tmp.Sub(`/orders{?fields}`)
+	require.Error(t, err)
+	require.Equal(t, `duplicate query param 'fields'`, err.Error())
+
+	tmp3, err := tmp2.ResolveTo(Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/users/1/orders/{order-id}{?fields,status}`, tmp3.OriginalTemplate())
+	vars, ok = tmp3.Matches(`/users/1/orders/2?fields=a`)
+	require.True(t, ok)
+	require.Equal(t, 2, vars.Len())
+}
+
+func TestRouter_QuerySections(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/search{?q}`), "optional"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/search{?id!}`), "by-id"))
+	err := r.Add(MustCreateTemplate(`/search{?id!}`), "dup")
+	require.Error(t, err)
+	require.Equal(t, `template '/search{?id!}' is shadowed by template '/search{?id!}'`, err.Error())
+
+	m, ok := r.Match(`/search?id=1`)
+	require.True(t, ok)
+	require.Equal(t, "by-id", m.Payload)
+	m, ok = r.Match(`/search?q=go`)
+	require.True(t, ok)
+	require.Equal(t, "optional", m.Payload)
+}
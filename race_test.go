@@ -0,0 +1,8 @@
+//go:build race
+
+package urit
+
+func init() {
+	// the race detector randomly drops sync.Pool items - so allocations are not deterministic
+	raceEnabled = true
+}
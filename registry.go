@@ -0,0 +1,182 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewRegistry creates a new, empty, Registry
+//
+// The options can be a HostOption (the default host for generated URLs) and/or a BasePathOption (a path prefix for generated URLs)
+func NewRegistry(options ...interface{}) Registry {
+	result := &registry{
+		templates: map[string]Template{},
+	}
+	for _, o := range options {
+		if h, ok := o.(HostOption); ok {
+			result.host = h
+		} else if bp, ok := o.(BasePathOption); ok {
+			result.basePath = normalizeBasePath(bp.GetBasePath())
+		}
+	}
+	return result
+}
+
+// Registry is the interface for a reverse routing registry - templates registered under route names (e.g. "order.get")
+// so that URLs can be generated by route name
+type Registry interface {
+	// Register registers a template under a route name
+	//
+	// returns an error if the name is empty or already registered
+	Register(name string, template Template) error
+	// Get returns the template registered under the route name
+	Get(name string) (Template, bool)
+	// URL generates a URL for the named route given the specified path vars
+	//
+	// the registry host and base path are prefixed (unless the template has its own host) - a HostOption passed
+	// in the options overrides the registry host
+	//
+	// returns an error if the route name is not registered
+	URL(name string, vars PathVars, options ...interface{}) (string, error)
+	// Names returns the registered route names (sorted)
+	Names() []string
+	// Export returns the templates (prefixed with the registry base path) mapped by route name (e.g. for passing to a front-end)
+	Export(removePatterns bool) map[string]string
+}
+
+// BasePathOption is the option interface for a base path prefix (e.g. for a Registry)
+type BasePathOption interface {
+	GetBasePath() string
+}
+
+// NewBasePath creates a new BasePathOption for the specified path (e.g. `/api/v1`)
+func NewBasePath(path string) BasePathOption {
+	return &basePath{
+		path: path,
+	}
+}
+
+type basePath struct {
+	path string
+}
+
+func (bp *basePath) GetBasePath() string {
+	return bp.path
+}
+
+func normalizeBasePath(path string) string {
+	path = strings.TrimRight(path, "/")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+type registry struct {
+	templates map[string]Template
+	host      HostOption
+	basePath  string
+	mutex     sync.RWMutex
+}
+
+// Register registers a template under a route name
+//
+// returns an error if the name is empty or already registered
+func (r *registry) Register(name string, template Template) error {
+	if name == "" {
+		return errors.New("route name cannot be empty")
+	} else if template == nil {
+		return fmt.Errorf("route '%s' template cannot be nil", name)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.templates[name]; exists {
+		return fmt.Errorf("route '%s' already registered", name)
+	}
+	r.templates[name] = template
+	return nil
+}
+
+// Get returns the template registered under the route name
+func (r *registry) Get(name string) (Template, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	t, ok := r.templates[name]
+	return t, ok
+}
+
+// URL generates a URL for the named route given the specified path vars
+//
+// the registry host and base path are prefixed (unless the template has its own host) - a HostOption passed
+// in the options overrides the registry host
+//
+// returns an error if the route name is not registered
+func (r *registry) URL(name string, vars PathVars, options ...interface{}) (string, error) {
+	t, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown route '%s'", name)
+	}
+	host := r.host
+	pathOptions := make([]interface{}, 0, len(options))
+	for _, o := range options {
+		if h, ok := o.(HostOption); ok {
+			host = h
+		} else {
+			pathOptions = append(pathOptions, o)
+		}
+	}
+	pth, err := t.PathFrom(vars, pathOptions...)
+	if err != nil || hasOwnHost(t) {
+		return pth, err
+	}
+	var ub strings.Builder
+	if host != nil {
+		ub.WriteString(host.GetAddress())
+	}
+	ub.WriteString(r.basePath)
+	if r.basePath == "" || pth != "/" {
+		ub.WriteString(pth)
+	}
+	return ub.String(), nil
+}
+
+// Names returns the registered route names (sorted)
+func (r *registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	result := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Export returns the templates (prefixed with the registry base path) mapped by route name (e.g. for passing to a front-end)
+func (r *registry) Export(removePatterns bool) map[string]string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	result := make(map[string]string, len(r.templates))
+	for name, t := range r.templates {
+		if hasOwnHost(t) {
+			result[name] = t.Template(removePatterns)
+		} else {
+			result[name] = r.basePath + t.Template(removePatterns)
+		}
+	}
+	return result
+}
+
+// hasOwnHost determines whether the template has its own scheme/host (so the registry host and base path are not used)
+func hasOwnHost(t Template) bool {
+	switch rt := t.(type) {
+	case *template:
+		return rt.host != nil
+	case *rfc6570Template:
+		return rt.absolute
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRegistry_URL(t *testing.T) {
+	r := NewRegistry(NewHost("https://www.example.com"), NewBasePath("api/v1/"))
+	require.NoError(t, r.Register("order.get", MustCreateTemplate(`/orders/{id:[0-9]+}`)))
+	require.NoError(t, r.Register("orders.search", MustCreateTemplate(`/orders{?q}`)))
+	require.NoError(t, r.Register("root", MustCreateTemplate(`/`)))
+	require.NoError(t, r.Register("tenant", MustCreateTemplate(`https://{tenant}.example.com/orders`)))
+
+	u, err := r.URL("order.get", Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/api/v1/orders/1`, u)
+	u, err = r.URL("order.get", Named("id", 1), NewHost("http://localhost:8080"))
+	require.NoError(t, err)
+	require.Equal(t, `http://localhost:8080/api/v1/orders/1`, u)
+	q, _ := NewQueryParams("q", "go")
+	u, err = r.URL("orders.search", nil, q)
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/api/v1/orders?q=go`, u)
+	u, err = r.URL("root", nil)
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/api/v1`, u)
+	u, err = r.URL("tenant", Named("tenant", "acme"))
+	require.NoError(t, err)
+	require.Equal(t, `https://acme.example.com/orders`, u)
+	require.NoError(t, r.Register("rfc.users", MustCreateRfc6570Template(`/users{/id}`)))
+	u, err = r.URL("rfc.users", Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/api/v1/users/1`, u)
+	require.NoError(t, r.Register("rfc.tenant", MustCreateRfc6570Template(`https://{tenant}.example.com/orders`)))
+	u, err = r.URL("rfc.tenant", Named("tenant", "acme"))
+	require.NoError(t, err)
+	require.Equal(t, `https://acme.example.com/orders`, u)
+
+	_, err = r.URL("order.get", Named())
+	require.Error(t, err)
+	require.Equal(t, `no var for 'id'`, err.Error())
+	_, err = r.URL("unknown", nil)
+	require.Error(t, err)
+	require.Equal(t, `unknown route 'unknown'`, err.Error())
+
+	r = NewRegistry()
+	require.NoError(t, r.Register("order.get", MustCreateTemplate(`/orders/{id}`)))
+	u, err = r.URL("order.get", Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/orders/1`, u)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	tmp := MustCreateTemplate(`/orders/{id}`)
+	require.NoError(t, r.Register("order.get", tmp))
+	err := r.Register("order.get", tmp)
+	require.Error(t, err)
+	require.Equal(t, `route 'order.get' already registered`, err.Error())
+	err = r.Register("", tmp)
+	require.Error(t, err)
+	require.Equal(t, `route name cannot be empty`, err.Error())
+	err = r.Register("x", nil)
+	require.Error(t, err)
+	require.Equal(t, `route 'x' template cannot be nil`, err.Error())
+
+	require.NoError(t, r.Register("a", tmp))
+	require.Equal(t, []string{"a", "order.get"}, r.Names())
+	gt, ok := r.Get("order.get")
+	require.True(t, ok)
+	require.Equal(t, tmp, gt)
+	_, ok = r.Get("unknown")
+	require.False(t, ok)
+}
+
+func TestRegistry_Export(t *testing.T) {
+	r := NewRegistry(NewBasePath("/api"))
+	require.NoError(t, r.Register("order.get", MustCreateTemplate(`/orders/{id:[0-9]+}`)))
+	require.NoError(t, r.Register("tenant", MustCreateTemplate(`https://{tenant}.example.com/orders`)))
+	require.NoError(t, r.Register("rfc.tenant", MustCreateRfc6570Template(`https://{tenant}.example.com/orders{/id}`)))
+	require.Equal(t, map[string]string{
+		"order.get":  `/api/orders/{id:[0-9]+}`,
+		"tenant":     `https://{tenant}.example.com/orders`,
+		"rfc.tenant": `https://{tenant}.example.com/orders{/id}`,
+	}, r.Export(false))
+	require.Equal(t, map[string]string{
+		"order.get":  `/api/orders/{id}`,
+		"tenant":     `https://{tenant}.example.com/orders`,
+		"rfc.tenant": `https://{tenant}.example.com/orders{/id}`,
+	}, r.Export(true))
+}
@@ -0,0 +1,873 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewRfc6570Template creates a new URI template from an RFC 6570 URI Template (levels 1 to 4) -
+// e.g. `/users{/id}{?fields,limit}` or `{+base}/x{#frag}`
+//
+// returns an error if the template cannot be parsed
+//
+// Paths are generated (using Template.PathFrom or Template.RequestFrom) by expanding the template expressions
+// from the path vars - any vars not found in the path vars are looked up in a QueryParams option (and any query
+// params not used by the expressions are added to the query)
+//
+// Paths are matched by reversing the template expressions - although, by the nature of RFC 6570, matching is less
+// precise than expansion (e.g. exploded associative values cannot be matched - paths expanded from them do not match, nor
+// do exploded values containing `=` for reserved expansion - and query params may be in any order and are always optional)
+//
+// The options can be any FixedMatchOption or VarMatchOption
+func NewRfc6570Template(template string, options ...interface{}) (Template, error) {
+	fs, vs, _ := separateParseOptions(options)
+	result := &rfc6570Template{
+		originalTemplate: template,
+		fixedMatchOpts:   fs,
+		varMatchOpts:     vs,
+	}
+	if err := result.parse(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MustCreateRfc6570Template is the same as NewRfc6570Template, except that it panics on error
+func MustCreateRfc6570Template(template string, options ...interface{}) Template {
+	if t, err := NewRfc6570Template(template, options...); err != nil {
+		panic(err)
+	} else {
+		return t
+	}
+}
+
+type rfc6570Template struct {
+	originalTemplate string
+	parts            []rfc6570Part
+	varNames         []string
+	absolute         bool
+	pathRegexp       *regexp.Regexp
+	pathRegexpCI     *regexp.Regexp
+	captures         map[int]*rfc6570Capture
+	queryExprs       []*rfc6570Expression
+	queryLiterals    url.Values
+	fixedMatchOpts   fixedMatchOptions
+	varMatchOpts     varMatchOptions
+}
+
+type rfc6570Part struct {
+	literal string
+	expr    *rfc6570Expression
+}
+
+type rfc6570Expression struct {
+	op   *rfc6570Operator
+	vars []*rfc6570VarSpec
+}
+
+type rfc6570VarSpec struct {
+	name    string
+	explode bool
+	prefix  int
+}
+
+type rfc6570Operator struct {
+	char          byte
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+type rfc6570Capture struct {
+	spec *rfc6570VarSpec
+	op   *rfc6570Operator
+}
+
+var rfc6570Operators = map[byte]*rfc6570Operator{
+	0:   {char: 0, first: "", sep: ",", named: false, ifEmpty: "", allowReserved: false},
+	'+': {char: '+', first: "", sep: ",", named: false, ifEmpty: "", allowReserved: true},
+	'.': {char: '.', first: ".", sep: ".", named: false, ifEmpty: "", allowReserved: false},
+	'/': {char: '/', first: "/", sep: "/", named: false, ifEmpty: "", allowReserved: false},
+	';': {char: ';', first: ";", sep: ";", named: true, ifEmpty: "", allowReserved: false},
+	'?': {char: '?', first: "?", sep: "&", named: true, ifEmpty: "=", allowReserved: false},
+	'&': {char: '&', first: "&", sep: "&", named: true, ifEmpty: "=", allowReserved: false},
+	'#': {char: '#', first: "#", sep: ",", named: false, ifEmpty: "", allowReserved: true},
+}
+
+const (
+	rfc6570UnreservedClass = `(?:[A-Za-z0-9\-._~]|%[0-9A-Fa-f]{2})`
+	rfc6570ReservedClass   = `(?:[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=]|%[0-9A-Fa-f]{2})`
+)
+
+// PathFrom generates a path from the template given the specified path vars
+func (t *rfc6570Template) PathFrom(vars PathVars, options ...interface{}) (string, error) {
+	hostOption, queryOption, _, varMatches, encoding := separatePathOptions(options)
+	return t.buildPath(vars, hostOption, queryOption, varMatches, encoding)
+}
+
+func (t *rfc6570Template) buildPath(vars PathVars, hostOption HostOption, queryOption QueryParamsOption, varMatches varMatchOptions, encoding EncodingOption) (string, error) {
+	var pb strings.Builder
+	if hostOption != nil {
+		pb.WriteString(hostOption.GetAddress())
+	}
+	lookup := &rfc6570Lookup{
+		template: t,
+		vars:     vars,
+		used:     map[string]bool{},
+	}
+	lookup.params, _ = queryOption.(QueryParams)
+	for _, pt := range t.parts {
+		if pt.expr == nil {
+			pb.WriteString(pt.literal)
+		} else if err := pt.expr.expand(&pb, lookup, varMatches, encoding); err != nil {
+			return "", err
+		}
+	}
+	if queryOption != nil {
+		if lookup.params != nil {
+			remaining := lookup.params.Clone()
+			for name := range lookup.used {
+				remaining.Del(name)
+			}
+			queryOption = remaining
+		}
+		q, err := queryOption.GetQuery()
+		if err != nil {
+			return "", err
+		} else if q != "" && strings.Contains(pb.String(), "?") {
+			q = "&" + q[1:]
+		}
+		pb.WriteString(q)
+	}
+	return pb.String(), nil
+}
+
+// RequestFrom generates a http.Request from the template given the specified path vars
+func (t *rfc6570Template) RequestFrom(method string, vars PathVars, body io.Reader, options ...interface{}) (*http.Request, error) {
+	hostOption, queryOption, headerOption, varMatches, encoding := separatePathOptions(options)
+	url, err := t.buildPath(vars, hostOption, queryOption, varMatches, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return newRequest(method, url, body, headerOption)
+}
+
+// PathFromStruct generates a path from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+//
+// (RFC 6570 template vars are never required - so missing vars are expanded as undefined)
+func (t *rfc6570Template) PathFromStruct(v interface{}, options ...interface{}) (string, error) {
+	vars, params, err := structVars(v, Names)
+	if err != nil {
+		return "", err
+	}
+	return t.PathFrom(vars, structPathOptions(params, options)...)
+}
+
+// RequestFromStruct generates a http.Request from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+//
+// (RFC 6570 template vars are never required - so missing vars are expanded as undefined)
+func (t *rfc6570Template) RequestFromStruct(method string, v interface{}, body io.Reader, options ...interface{}) (*http.Request, error) {
+	vars, params, err := structVars(v, Names)
+	if err != nil {
+		return nil, err
+	}
+	return t.RequestFrom(method, vars, body, structPathOptions(params, options)...)
+}
+
+// Matches checks whether the specified path matches the template -
+// and if a successful match, returns the extracted path vars
+func (t *rfc6570Template) Matches(path string, options ...interface{}) (PathVars, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, false
+	}
+	return t.MatchesUrl(*u, options...)
+}
+
+// MatchesUrl checks whether the specified URL path matches the template -
+// and if successful match, returns the extracted path vars
+func (t *rfc6570Template) MatchesUrl(u url.URL, options ...interface{}) (PathVars, bool) {
+	target, ok := normalizedPath(u.EscapedPath(), nil, options)
+	if !ok {
+		return nil, false
+	}
+	if t.absolute && u.Host != "" {
+		target = u.Scheme + "://" + u.Host + target
+	}
+	if u.Fragment != "" {
+		target = target + "#" + u.EscapedFragment()
+	}
+	return t.matches(target, u.RawQuery, options)
+}
+
+// MatchesRequest checks whether the specified request matches the template -
+// and if a successful match, returns the extracted path vars
+func (t *rfc6570Template) MatchesRequest(req *http.Request, options ...interface{}) (PathVars, bool) {
+	u := *req.URL
+	if u.Host == "" && req.Host != "" {
+		u.Host = req.Host
+		if u.Scheme == "" {
+			u.Scheme = "http"
+			if req.TLS != nil {
+				u.Scheme = "https"
+			}
+		}
+	}
+	return t.MatchesUrl(u, options...)
+}
+
+func (t *rfc6570Template) matches(target string, rawQuery string, options []interface{}) (PathVars, bool) {
+	fixedOpts, varOpts := mergeParseOptions(t.fixedMatchOpts, t.varMatchOpts, options)
+	encoding := encodingOption(options)
+	rx := t.pathRegexp
+	for _, f := range fixedOpts {
+		if f == _CaseInsensitiveFixed {
+			rx = t.pathRegexpCI
+		}
+	}
+	idxs := rx.FindStringSubmatchIndex(target)
+	if idxs == nil {
+		return nil, false
+	}
+	result := newPathVars(Names)
+	names := rx.SubexpNames()
+	for i := 1; i < len(names); i++ {
+		if c, ok := t.captures[i]; ok && idxs[i*2] != -1 {
+			// 'd' groups are defined by participating in the match (even if empty)...
+			if str := target[idxs[i*2]:idxs[i*2+1]]; str != "" || names[i][0] == 'd' {
+				values, ok := c.values(str)
+				if !ok {
+					return nil, false
+				}
+				for _, v := range values {
+					if !addRfc6570Var(result, c.spec.name, v, encoding, varOpts) {
+						return nil, false
+					}
+				}
+			}
+		}
+	}
+	if len(t.queryExprs) > 0 || len(t.queryLiterals) > 0 {
+		q, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return nil, false
+		}
+		for k, vs := range t.queryLiterals {
+			if len(q[k]) == 0 || q[k][0] != vs[0] {
+				return nil, false
+			}
+		}
+		for _, expr := range t.queryExprs {
+			for _, spec := range expr.vars {
+				for _, v := range q[spec.name] {
+					if !addRfc6570Var(result, spec.name, v, _PreEncoded, varOpts) {
+						return nil, false
+					}
+				}
+			}
+		}
+	}
+	return result, true
+}
+
+func addRfc6570Var(vars PathVars, name string, value string, encoding EncodingOption, varOpts varMatchOptions) bool {
+	value, ok := encoding.Decode(value)
+	if !ok {
+		return false
+	}
+	if len(varOpts) > 0 {
+		if rs, vok, applicable := varOpts.check(value, vars.Len(), name, nil, "", -1, vars); applicable {
+			if !vok {
+				return false
+			}
+			value = rs
+		}
+	}
+	_ = vars.AddNamedValue(name, value)
+	return true
+}
+
+// Sub generates a new template with added sub-path
+func (t *rfc6570Template) Sub(path string, options ...interface{}) (Template, error) {
+	add := path
+	if strings.HasSuffix(t.originalTemplate, "/") && strings.HasPrefix(add, "/") {
+		add = add[1:]
+	}
+	return NewRfc6570Template(t.originalTemplate+add, append(t.Options(), options...)...)
+}
+
+// ResolveTo generates a new template, filling in any known path vars from the supplied vars
+//
+// Only expressions where all the vars are known are resolved
+func (t *rfc6570Template) ResolveTo(vars PathVars) (Template, error) {
+	lookup := &rfc6570Lookup{
+		template: t,
+		vars:     vars,
+		used:     map[string]bool{},
+	}
+	var ob strings.Builder
+	for _, pt := range t.parts {
+		if pt.expr == nil {
+			ob.WriteString(pt.literal)
+		} else if ok, err := pt.expr.resolvable(lookup); err != nil {
+			return nil, err
+		} else if !ok {
+			ob.WriteString(pt.expr.String())
+		} else if err = pt.expr.expand(&ob, lookup, nil, _PercentEncoded); err != nil {
+			return nil, err
+		}
+	}
+	return NewRfc6570Template(ob.String(), t.Options()...)
+}
+
+// VarsType returns the path vars type (always Names for RFC 6570 templates)
+func (t *rfc6570Template) VarsType() PathVarsType {
+	return Names
+}
+
+// Vars returns the path vars of the template
+func (t *rfc6570Template) Vars() []PathVar {
+	result := make([]PathVar, 0)
+	namePosns := map[string]int{}
+	for _, pt := range t.parts {
+		if pt.expr != nil {
+			for _, spec := range pt.expr.vars {
+				result = append(result, PathVar{
+					Name:          spec.name,
+					NamedPosition: namePosns[spec.name],
+					Position:      len(result),
+				})
+				namePosns[spec.name]++
+			}
+		}
+	}
+	return result
+}
+
+// OriginalTemplate returns the original (or generated) path template string
+func (t *rfc6570Template) OriginalTemplate() string {
+	return t.originalTemplate
+}
+
+// Template returns the template (RFC 6570 templates have no path var patterns, so this is always the original template)
+func (t *rfc6570Template) Template(removePatterns bool) string {
+	return t.originalTemplate
+}
+
+// Options returns the options the template was created with (any FixedMatchOption or VarMatchOption)
+//
+// templates generated using Sub or ResolveTo inherit the options of the template
+func (t *rfc6570Template) Options() []interface{} {
+	result := make([]interface{}, 0, len(t.fixedMatchOpts)+len(t.varMatchOpts))
+	for _, o := range t.fixedMatchOpts {
+		result = append(result, o)
+	}
+	for _, o := range t.varMatchOpts {
+		result = append(result, o)
+	}
+	return result
+}
+
+func (t *rfc6570Template) parse() error {
+	s := t.originalTemplate
+	if strings.Trim(s, " ") == "" {
+		return newTemplateParseError("template empty", 0, nil)
+	}
+	t.parts = make([]rfc6570Part, 0)
+	seenNames := map[string]bool{}
+	var lb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			end := strings.IndexByte(s[i+1:], '}')
+			if end == -1 {
+				return newTemplateParseError(fmt.Sprintf("unclosed '{' at position %d", i), i, nil)
+			}
+			if lb.Len() > 0 {
+				t.parts = append(t.parts, rfc6570Part{literal: lb.String()})
+				lb.Reset()
+			}
+			expr, err := parseRfc6570Expression(s[i+1:i+1+end], i)
+			if err != nil {
+				return err
+			}
+			for _, spec := range expr.vars {
+				if !seenNames[spec.name] {
+					seenNames[spec.name] = true
+					t.varNames = append(t.varNames, spec.name)
+				}
+			}
+			t.parts = append(t.parts, rfc6570Part{expr: expr})
+			i += end + 1
+		case '}':
+			return newTemplateParseError(fmt.Sprintf("unopened '}' at position %d", i), i, nil)
+		default:
+			lb.WriteByte(s[i])
+		}
+	}
+	if lb.Len() > 0 {
+		t.parts = append(t.parts, rfc6570Part{literal: lb.String()})
+	}
+	return t.compile()
+}
+
+// compile builds the regexp (and query expressions) used for matching
+func (t *rfc6570Template) compile() error {
+	t.absolute = len(t.parts) > 0 && !strings.HasPrefix(t.parts[0].literal, "/")
+	t.captures = map[int]*rfc6570Capture{}
+	t.queryExprs = make([]*rfc6570Expression, 0)
+	t.queryLiterals = url.Values{}
+	var rxb strings.Builder
+	inQuery := false
+	groups := make([]*rfc6570Capture, 0)
+	for _, pt := range t.parts {
+		if pt.expr != nil {
+			if pt.expr.op.char == '#' {
+				inQuery = false
+			} else if pt.expr.op.char == '?' || pt.expr.op.char == '&' {
+				inQuery = true
+			}
+			if inQuery {
+				t.queryExprs = append(t.queryExprs, pt.expr)
+			} else {
+				pt.expr.buildRegexp(&rxb, &groups)
+			}
+		} else {
+			lit := pt.literal
+			if inQuery || strings.ContainsRune(lit, '?') {
+				if !inQuery {
+					qAt := strings.IndexByte(lit, '?')
+					rxb.WriteString(regexp.QuoteMeta(lit[:qAt]))
+					lit = lit[qAt+1:]
+					inQuery = true
+				}
+				if hAt := strings.IndexByte(lit, '#'); hAt != -1 {
+					t.addQueryLiterals(lit[:hAt])
+					lit = lit[hAt:]
+					inQuery = false
+				} else {
+					t.addQueryLiterals(lit)
+					lit = ""
+				}
+			}
+			rxb.WriteString(regexp.QuoteMeta(lit))
+		}
+	}
+	rxs := "^" + rxb.String() + "$"
+	rx, err := regexp.Compile(rxs)
+	if err != nil {
+		return newTemplateParseError("template regexp problem", 0, err)
+	}
+	t.pathRegexp = rx
+	t.pathRegexpCI = regexp.MustCompile("(?i)" + rxs)
+	for i, nm := range rx.SubexpNames() {
+		if nm != "" {
+			gi, _ := strconv.Atoi(nm[1:])
+			t.captures[i] = groups[gi]
+		}
+	}
+	return nil
+}
+
+func (t *rfc6570Template) addQueryLiterals(lit string) {
+	for _, pr := range strings.Split(lit, "&") {
+		if pr != "" {
+			k, v, _ := strings.Cut(pr, "=")
+			ku, _ := url.QueryUnescape(k)
+			vu, _ := url.QueryUnescape(v)
+			t.queryLiterals.Add(ku, vu)
+		}
+	}
+}
+
+func parseRfc6570Expression(s string, pos int) (*rfc6570Expression, error) {
+	if s == "" {
+		return nil, newTemplateParseError("expression cannot be empty", pos, nil)
+	}
+	op := rfc6570Operators[0]
+	if o, ok := rfc6570Operators[s[0]]; ok && s[0] != 0 {
+		op = o
+		s = s[1:]
+	} else if strings.IndexByte("=,!@|", s[0]) != -1 {
+		return nil, newTemplateParseError(fmt.Sprintf("unsupported expression operator '%c'", s[0]), pos+1, nil)
+	}
+	result := &rfc6570Expression{
+		op:   op,
+		vars: make([]*rfc6570VarSpec, 0),
+	}
+	for _, vs := range strings.Split(s, ",") {
+		spec := &rfc6570VarSpec{}
+		if strings.HasSuffix(vs, "*") {
+			spec.explode = true
+			vs = vs[:len(vs)-1]
+		} else if cAt := strings.IndexByte(vs, ':'); cAt != -1 {
+			n, err := strconv.Atoi(vs[cAt+1:])
+			if err != nil || n < 1 || n > 9999 {
+				return nil, newTemplateParseError("invalid prefix modifier", pos, err)
+			}
+			spec.prefix = n
+			vs = vs[:cAt]
+		}
+		if !isRfc6570VarName(vs) {
+			return nil, newTemplateParseError(fmt.Sprintf("invalid variable name '%s'", vs), pos, nil)
+		}
+		spec.name = vs
+		result.vars = append(result.vars, spec)
+	}
+	return result, nil
+}
+
+func isRfc6570VarName(s string) bool {
+	if s == "" || s[0] == '.' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' {
+			if i+2 >= len(s) || !isHexChar(s[i+1]) || !isHexChar(s[i+2]) {
+				return false
+			}
+			i += 2
+		} else if !(('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexChar(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func (e *rfc6570Expression) String() string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	if e.op.char != 0 {
+		sb.WriteByte(e.op.char)
+	}
+	for i, spec := range e.vars {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(spec.name)
+		if spec.explode {
+			sb.WriteString("*")
+		} else if spec.prefix > 0 {
+			sb.WriteString(":" + strconv.Itoa(spec.prefix))
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (e *rfc6570Expression) resolvable(lookup *rfc6570Lookup) (bool, error) {
+	for _, spec := range e.vars {
+		if v, err := lookup.get(spec.name); err != nil {
+			return false, err
+		} else if !v.defined {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// expand expands the expression (as per RFC 6570 appendix A)
+func (e *rfc6570Expression) expand(sb *strings.Builder, lookup *rfc6570Lookup, varMatches varMatchOptions, encoding EncodingOption) error {
+	op := e.op
+	first := true
+	for _, spec := range e.vars {
+		v, err := lookup.get(spec.name)
+		if err != nil {
+			return err
+		} else if !v.defined {
+			continue
+		}
+		if first {
+			sb.WriteString(op.first)
+			first = false
+		} else {
+			sb.WriteString(op.sep)
+		}
+		switch {
+		case v.list == nil && v.keys == nil:
+			s := v.str
+			for _, ck := range varMatches {
+				if ck.Applicable(s, 0, spec.name, nil, "", -1, lookup.vars) {
+					if altS, ok := ck.Match(s, 0, spec.name, nil, "", -1, lookup.vars); ok {
+						s = altS
+					} else {
+						return errors.New("no match path var")
+					}
+				}
+			}
+			if spec.prefix > 0 && utf8.RuneCountInString(s) > spec.prefix {
+				s = string([]rune(s)[:spec.prefix])
+			}
+			if op.named {
+				sb.WriteString(spec.name)
+				if s == "" {
+					sb.WriteString(op.ifEmpty)
+					continue
+				}
+				sb.WriteString("=")
+			}
+			sb.WriteString(rfc6570Encode(s, op.allowReserved, encoding))
+		case !spec.explode:
+			if op.named {
+				sb.WriteString(spec.name + "=")
+			}
+			if v.keys != nil {
+				for i, k := range v.keys {
+					if i > 0 {
+						sb.WriteString(",")
+					}
+					sb.WriteString(rfc6570Encode(k, op.allowReserved, encoding) + "," + rfc6570Encode(v.list[i], op.allowReserved, encoding))
+				}
+			} else {
+				for i, item := range v.list {
+					if i > 0 {
+						sb.WriteString(",")
+					}
+					sb.WriteString(rfc6570Encode(item, op.allowReserved, encoding))
+				}
+			}
+		default:
+			for i, item := range v.list {
+				if i > 0 {
+					sb.WriteString(op.sep)
+				}
+				name := spec.name
+				if v.keys != nil {
+					name = rfc6570Encode(v.keys[i], op.allowReserved, encoding)
+				}
+				if op.named || v.keys != nil {
+					sb.WriteString(name)
+					if item == "" {
+						sb.WriteString(op.ifEmpty)
+						continue
+					}
+					sb.WriteString("=")
+				}
+				sb.WriteString(rfc6570Encode(item, op.allowReserved, encoding))
+			}
+		}
+	}
+	return nil
+}
+
+// buildRegexp builds the regexp for matching the (non-query) expression
+func (e *rfc6570Expression) buildRegexp(rxb *strings.Builder, groups *[]*rfc6570Capture) {
+	op := e.op
+	class := rfc6570UnreservedClass
+	if op.allowReserved {
+		class = rfc6570ReservedClass
+	}
+	group := func(spec *rfc6570VarSpec, kind string, pattern string) string {
+		*groups = append(*groups, &rfc6570Capture{spec: spec, op: op})
+		return `(?P<` + kind + strconv.Itoa(len(*groups)-1) + `>` + pattern + `)`
+	}
+	valuePattern := func(spec *rfc6570VarSpec, withSep bool) string {
+		if spec.prefix > 0 {
+			return class + `{0,` + strconv.Itoa(spec.prefix) + `}`
+		} else if withSep {
+			return `(?:` + class + `|,)*`
+		}
+		return class + `*`
+	}
+	if op.first == op.sep {
+		// '.', '/' & ';' operators...
+		sep := regexp.QuoteMeta(op.sep)
+		for _, spec := range e.vars {
+			item := valuePattern(spec, false)
+			if op.named {
+				item = regexp.QuoteMeta(spec.name) + `(?:=` + item + `)?`
+			}
+			if spec.explode {
+				rxb.WriteString(group(spec, "x", `(?:`+sep+item+`)*`))
+			} else {
+				rxb.WriteString(`(?:` + sep + group(spec, "d", item) + `)?`)
+			}
+		}
+		return
+	}
+	// simple, '+' & '#' operators...
+	rxb.WriteString(`(?:` + regexp.QuoteMeta(op.first))
+	for i, spec := range e.vars {
+		pattern := valuePattern(spec, len(e.vars) == 1 || spec.explode)
+		if i == 0 {
+			rxb.WriteString(group(spec, "v", pattern))
+		} else {
+			rxb.WriteString(`(?:,` + group(spec, "v", pattern) + `)?`)
+		}
+	}
+	rxb.WriteString(`)?`)
+}
+
+// values returns the values from a matched capture
+//
+// returns false if the capture is an exploded associative value (i.e. `key=value` pairs) - which cannot be matched
+func (c *rfc6570Capture) values(s string) ([]string, bool) {
+	if c.spec.explode {
+		if c.op.first == c.op.sep {
+			items := strings.Split(s, c.op.sep)[1:]
+			if c.op.named {
+				for i, item := range items {
+					items[i] = strings.TrimPrefix(strings.TrimPrefix(item, c.spec.name), "=")
+				}
+			}
+			return items, true
+		}
+		// only reserved expansions ('+' & '#') can have an unencoded '=' - which is an exploded associative value...
+		if strings.IndexByte(s, '=') != -1 {
+			return nil, false
+		}
+		return strings.Split(s, ","), true
+	} else if c.op.named {
+		return []string{strings.TrimPrefix(strings.TrimPrefix(s, c.spec.name), "=")}, true
+	}
+	return []string{s}, true
+}
+
+func rfc6570Encode(s string, allowReserved bool, encoding EncodingOption) string {
+	if encoding == _PreEncoded {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreservedChar(c) {
+			sb.WriteByte(c)
+		} else if allowReserved && (isSubDelimChar(c) || strings.IndexByte(":/?#[]@", c) != -1) {
+			sb.WriteByte(c)
+		} else if allowReserved && c == '%' && i+2 < len(s) && isHexChar(s[i+1]) && isHexChar(s[i+2]) {
+			sb.WriteString(s[i : i+3])
+			i += 2
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(upperHex[c>>4])
+			sb.WriteByte(upperHex[c&15])
+		}
+	}
+	return sb.String()
+}
+
+type rfc6570Lookup struct {
+	template *rfc6570Template
+	vars     PathVars
+	params   QueryParams
+	used     map[string]bool
+}
+
+type rfc6570Value struct {
+	defined bool
+	str     string
+	list    []string
+	keys    []string
+}
+
+// get looks up the value for a var name - first in the path vars, then in the query params
+func (l *rfc6570Lookup) get(name string) (rfc6570Value, error) {
+	// names used by the template are never added as extra query params...
+	l.used[name] = true
+	values := make([]interface{}, 0)
+	if l.vars != nil && l.vars.VarsType() == Positions {
+		for i, nm := range l.template.varNames {
+			if nm == name && i < l.vars.Len() {
+				values = append(values, l.vars.GetAll()[i].Value)
+			}
+		}
+	} else if l.vars != nil {
+		for _, pv := range l.vars.GetAll() {
+			if pv.Name == name {
+				values = append(values, pv.Value)
+			}
+		}
+	}
+	if len(values) == 0 && l.params != nil {
+		for i := 0; ; i++ {
+			if v, ok := l.params.GetIndex(name, i); ok {
+				values = append(values, v)
+			} else {
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		return rfc6570Value{}, nil
+	} else if len(values) > 1 {
+		result := rfc6570Value{defined: true, list: make([]string, 0, len(values))}
+		for _, v := range values {
+			str, err := getValue(v)
+			if err != nil {
+				return result, err
+			}
+			result.list = append(result.list, str)
+		}
+		return result, nil
+	}
+	return rfc6570ValueOf(values[0])
+}
+
+func rfc6570ValueOf(v interface{}) (rfc6570Value, error) {
+	if v == nil {
+		return rfc6570Value{}, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Len() == 0 {
+			return rfc6570Value{}, nil
+		}
+		result := rfc6570Value{defined: true, list: make([]string, 0, rv.Len())}
+		for i := 0; i < rv.Len(); i++ {
+			str, err := getValue(rv.Index(i).Interface())
+			if err != nil {
+				return result, err
+			}
+			result.list = append(result.list, str)
+		}
+		return result, nil
+	case reflect.Map:
+		if rv.Len() == 0 {
+			return rfc6570Value{}, nil
+		}
+		result := rfc6570Value{defined: true, keys: make([]string, 0, rv.Len()), list: make([]string, 0, rv.Len())}
+		mks := rv.MapKeys()
+		keys := make([]string, 0, len(mks))
+		values := map[string]string{}
+		for _, mk := range mks {
+			k, err := getValue(mk.Interface())
+			if err != nil {
+				return result, err
+			}
+			str, err := getValue(rv.MapIndex(mk).Interface())
+			if err != nil {
+				return result, err
+			}
+			keys = append(keys, k)
+			values[k] = str
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.keys = append(result.keys, k)
+			result.list = append(result.list, values[k])
+		}
+		return result, nil
+	}
+	str, err := getValue(v)
+	if err != nil {
+		return rfc6570Value{}, err
+	}
+	return rfc6570Value{defined: true, str: str}, nil
+}
@@ -0,0 +1,445 @@
+package urit
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func rfc6570TestVars() PathVars {
+	return Named(
+		"var", "value",
+		"hello", "Hello World!",
+		"path", "/foo/bar",
+		"list", []string{"red", "green", "blue"},
+		"keys", map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"empty", "",
+		"x", 1024,
+		"y", 768)
+}
+
+func TestRfc6570Template_PathFrom(t *testing.T) {
+	// examples from RFC 6570 (associative keys are expanded in sorted order)...
+	testCases := []struct {
+		template string
+		expect   string
+	}{
+		// level 1...
+		{`{var}`, `value`},
+		{`{hello}`, `Hello%20World%21`},
+		// level 2...
+		{`{+var}`, `value`},
+		{`{+hello}`, `Hello%20World!`},
+		{`{+path}/here`, `/foo/bar/here`},
+		{`here?ref={+path}`, `here?ref=/foo/bar`},
+		{`X{#var}`, `X#value`},
+		{`X{#hello}`, `X#Hello%20World!`},
+		// level 3...
+		{`map?{x,y}`, `map?1024,768`},
+		{`{x,hello,y}`, `1024,Hello%20World%21,768`},
+		{`{+x,hello,y}`, `1024,Hello%20World!,768`},
+		{`{+path,x}/here`, `/foo/bar,1024/here`},
+		{`{#x,hello,y}`, `#1024,Hello%20World!,768`},
+		{`{#path,x}/here`, `#/foo/bar,1024/here`},
+		{`X{.var}`, `X.value`},
+		{`X{.x,y}`, `X.1024.768`},
+		{`{/var}`, `/value`},
+		{`{/var,x}/here`, `/value/1024/here`},
+		{`{;x,y}`, `;x=1024;y=768`},
+		{`{;x,y,empty}`, `;x=1024;y=768;empty`},
+		{`{?x,y}`, `?x=1024&y=768`},
+		{`{?x,y,empty}`, `?x=1024&y=768&empty=`},
+		{`?fixed=yes{&x}`, `?fixed=yes&x=1024`},
+		{`{&x,y,empty}`, `&x=1024&y=768&empty=`},
+		{`{undef}`, ``},
+		{`X{.undef}{/undef}{?undef}`, `X`},
+		// level 4...
+		{`{var:3}`, `val`},
+		{`{var:30}`, `value`},
+		{`{list}`, `red,green,blue`},
+		{`{list*}`, `red,green,blue`},
+		{`{keys}`, `comma,%2C,dot,.,semi,%3B`},
+		{`{keys*}`, `comma=%2C,dot=.,semi=%3B`},
+		{`{+path:6}/here`, `/foo/b/here`},
+		{`{+list}`, `red,green,blue`},
+		{`{+list*}`, `red,green,blue`},
+		{`{+keys}`, `comma,,,dot,.,semi,;`},
+		{`{+keys*}`, `comma=,,dot=.,semi=;`},
+		{`{#path:6}/here`, `#/foo/b/here`},
+		{`{#list}`, `#red,green,blue`},
+		{`{#list*}`, `#red,green,blue`},
+		{`{#keys*}`, `#comma=,,dot=.,semi=;`},
+		{`X{.var:3}`, `X.val`},
+		{`X{.list}`, `X.red,green,blue`},
+		{`X{.list*}`, `X.red.green.blue`},
+		{`X{.keys}`, `X.comma,%2C,dot,.,semi,%3B`},
+		{`X{.keys*}`, `X.comma=%2C.dot=..semi=%3B`},
+		{`{/var:1,var}`, `/v/value`},
+		{`{/list}`, `/red,green,blue`},
+		{`{/list*}`, `/red/green/blue`},
+		{`{/list*,path:4}`, `/red/green/blue/%2Ffoo`},
+		{`{/keys}`, `/comma,%2C,dot,.,semi,%3B`},
+		{`{/keys*}`, `/comma=%2C/dot=./semi=%3B`},
+		{`{;hello:5}`, `;hello=Hello`},
+		{`{;list}`, `;list=red,green,blue`},
+		{`{;list*}`, `;list=red;list=green;list=blue`},
+		{`{;keys}`, `;keys=comma,%2C,dot,.,semi,%3B`},
+		{`{;keys*}`, `;comma=%2C;dot=.;semi=%3B`},
+		{`{?var:3}`, `?var=val`},
+		{`{?list}`, `?list=red,green,blue`},
+		{`{?list*}`, `?list=red&list=green&list=blue`},
+		{`{?keys}`, `?keys=comma,%2C,dot,.,semi,%3B`},
+		{`{?keys*}`, `?comma=%2C&dot=.&semi=%3B`},
+		{`{&var:3}`, `&var=val`},
+		{`{&list}`, `&list=red,green,blue`},
+		{`{&list*}`, `&list=red&list=green&list=blue`},
+		{`{&keys}`, `&keys=comma,%2C,dot,.,semi,%3B`},
+		{`{&keys*}`, `&comma=%2C&dot=.&semi=%3B`},
+	}
+	vars := rfc6570TestVars()
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.template), func(t *testing.T) {
+			tmp, err := NewRfc6570Template(tc.template)
+			require.NoError(t, err)
+			require.Equal(t, tc.template, tmp.OriginalTemplate())
+			require.Equal(t, tc.template, tmp.Template(true))
+			require.Equal(t, Names, tmp.VarsType())
+			pth, err := tmp.PathFrom(vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pth)
+		})
+	}
+}
+
+func TestRfc6570Template_PathFrom_Positional(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}/orders{/order}`)
+	pth, err := tmp.PathFrom(Positional("abc", 123))
+	require.NoError(t, err)
+	require.Equal(t, `/users/abc/orders/123`, pth)
+	pth, err = tmp.PathFrom(Positional("abc"))
+	require.NoError(t, err)
+	require.Equal(t, `/users/abc/orders`, pth)
+}
+
+func TestRfc6570Template_PathFrom_WithOptions(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/search{?q,page}`)
+	q, err := NewQueryParams("q", "go lang", "page", 2, "extra", "x")
+	require.NoError(t, err)
+	pth, err := tmp.PathFrom(Named("page", 3), q, NewHost(`https://www.example.com`))
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/search?q=go%20lang&page=3&extra=x`, pth)
+
+	pth, err = tmp.PathFrom(nil, q)
+	require.NoError(t, err)
+	require.Equal(t, `/search?q=go%20lang&page=2&extra=x`, pth)
+
+	tmp = MustCreateRfc6570Template(`/files{/name}`)
+	pth, err = tmp.PathFrom(Named("name", "a%2Fb"), PreEncoded)
+	require.NoError(t, err)
+	require.Equal(t, `/files/a%2Fb`, pth)
+
+	tmp = MustCreateRfc6570Template(`/files{/id}`)
+	_, err = tmp.PathFrom(Named("id", "abc"), &uuidChecker{}, PathRegexCheck)
+	require.NoError(t, err)
+	_, err = tmp.PathFrom(Named("id", "abc"), &rejectingVar{})
+	require.Error(t, err)
+	require.Equal(t, `no match path var`, err.Error())
+
+	_, err = tmp.PathFrom(Named("id", func() bool {
+		return false
+	}))
+	require.Error(t, err)
+	require.Equal(t, `unknown value type`, err.Error())
+}
+
+func TestRfc6570Template_RequestFrom(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}{?fields}`)
+	hds, err := NewHeaders("Accept", "application/json")
+	require.NoError(t, err)
+	req, err := tmp.RequestFrom(http.MethodGet, Named("id", "123", "fields", []string{"a", "b"}), nil, NewHost(`https://www.example.com`), hds)
+	require.NoError(t, err)
+	require.Equal(t, `/users/123`, req.URL.Path)
+	require.Equal(t, `fields=a,b`, req.URL.RawQuery)
+	require.Equal(t, `application/json`, req.Header.Get("Accept"))
+
+	_, err = tmp.RequestFrom("£££", nil, nil)
+	require.Error(t, err)
+}
+
+func TestRfc6570Template_Matches(t *testing.T) {
+	testCases := []struct {
+		template   string
+		path       string
+		expectOk   bool
+		expectVars map[string][]string
+	}{
+		{
+			template:   `/users{/id}{?fields,limit}`,
+			path:       `/users/123?limit=10&fields=a&other=x`,
+			expectOk:   true,
+			expectVars: map[string][]string{"id": {"123"}, "fields": {"a"}, "limit": {"10"}},
+		},
+		{
+			template:   `/users{/id}{?fields,limit}`,
+			path:       `/users`,
+			expectOk:   true,
+			expectVars: map[string][]string{},
+		},
+		{
+			template: `/users{/id}{?fields,limit}`,
+			path:     `/users/123/456`,
+		},
+		{
+			template:   `{+base}/x{#frag}`,
+			path:       `http://example.com/api/x#top`,
+			expectOk:   true,
+			expectVars: map[string][]string{"base": {"http://example.com/api"}, "frag": {"top"}},
+		},
+		{
+			template:   `/files{/path*}`,
+			path:       `/files/a/b%20c/d`,
+			expectOk:   true,
+			expectVars: map[string][]string{"path": {"a", "b c", "d"}},
+		},
+		{
+			template:   `/map{;x,y,empty}`,
+			path:       `/map;x=1;y=2;empty`,
+			expectOk:   true,
+			expectVars: map[string][]string{"x": {"1"}, "y": {"2"}, "empty": {""}},
+		},
+		{
+			template:   `/map{;list*}`,
+			path:       `/map;list=a;list=b`,
+			expectOk:   true,
+			expectVars: map[string][]string{"list": {"a", "b"}},
+		},
+		{
+			template:   `/x{.fmt}`,
+			path:       `/x.json`,
+			expectOk:   true,
+			expectVars: map[string][]string{"fmt": {"json"}},
+		},
+		{
+			template:   `/x{.fmt}`,
+			path:       `/x.`,
+			expectOk:   true,
+			expectVars: map[string][]string{"fmt": {""}},
+		},
+		{
+			template:   `/x/{a,b}`,
+			path:       `/x/1,2`,
+			expectOk:   true,
+			expectVars: map[string][]string{"a": {"1"}, "b": {"2"}},
+		},
+		{
+			template:   `/x/{list}/{list2*}`,
+			path:       `/x/1,2/3,4`,
+			expectOk:   true,
+			expectVars: map[string][]string{"list": {"1,2"}, "list2": {"3", "4"}},
+		},
+		{
+			template:   `/x/{var:3}`,
+			path:       `/x/val`,
+			expectOk:   true,
+			expectVars: map[string][]string{"var": {"val"}},
+		},
+		{
+			template: `/x/{var:3}`,
+			path:     `/x/value`,
+		},
+		{
+			template:   `/search?fixed=yes{&q*}`,
+			path:       `/search?q=a&fixed=yes&q=b`,
+			expectOk:   true,
+			expectVars: map[string][]string{"q": {"a", "b"}},
+		},
+		{
+			template: `/search?fixed=yes{&q*}`,
+			path:     `/search?q=a`,
+		},
+		{
+			template: `/search{?q}`,
+			path:     `/search?q=%zz`,
+		},
+		{
+			template: `/x/{var}`,
+			path:     `/x/100%`,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.template), func(t *testing.T) {
+			tmp, err := NewRfc6570Template(tc.template)
+			require.NoError(t, err)
+			vars, ok := tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				count := 0
+				for k, vs := range tc.expectVars {
+					for vi, v := range vs {
+						av, ok := vars.GetNamed(k, vi)
+						require.True(t, ok)
+						require.Equal(t, v, av)
+						count++
+					}
+				}
+				require.Equal(t, count, vars.Len())
+			}
+		})
+	}
+}
+
+func TestRfc6570Template_Matches_ExplodedRoundTrip(t *testing.T) {
+	vars := rfc6570TestVars()
+	for _, op := range []string{"", "+", "#", ".", "/", ";"} {
+		t.Run(op, func(t *testing.T) {
+			// exploded lists round-trip...
+			tmp := MustCreateRfc6570Template(`/x{` + op + `list*}`)
+			pth, err := tmp.PathFrom(vars)
+			require.NoError(t, err)
+			mv, ok := tmp.Matches(pth)
+			require.True(t, ok, pth)
+			require.Equal(t, 3, mv.Len())
+			for i, expect := range []string{"red", "green", "blue"} {
+				v, _ := mv.GetNamed("list", i)
+				require.Equal(t, expect, v)
+			}
+			// exploded associative values cannot be matched...
+			tmp = MustCreateRfc6570Template(`/x{` + op + `keys*}`)
+			pth, err = tmp.PathFrom(vars)
+			require.NoError(t, err)
+			_, ok = tmp.Matches(pth)
+			require.False(t, ok, pth)
+		})
+	}
+	// query params are always optional - so associative values are not extracted...
+	tmp := MustCreateRfc6570Template(`/x{?keys*}`)
+	pth, err := tmp.PathFrom(vars)
+	require.NoError(t, err)
+	mv, ok := tmp.Matches(pth)
+	require.True(t, ok, pth)
+	require.Equal(t, 0, mv.Len())
+}
+
+func TestRfc6570Template_MatchesRequest(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`http://{host}/users{/id}`)
+	req, err := http.NewRequest(http.MethodGet, `http://www.example.com/users/123`, nil)
+	require.NoError(t, err)
+	vars, ok := tmp.MatchesRequest(req)
+	require.True(t, ok)
+	v, _ := vars.Get("host")
+	require.Equal(t, `www.example.com`, v)
+	v, _ = vars.Get("id")
+	require.Equal(t, `123`, v)
+
+	req.URL.Host = ""
+	_, ok = tmp.MatchesRequest(req)
+	require.True(t, ok)
+}
+
+func TestRfc6570Template_MatchesWithOptions(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/Users{/id}`)
+	_, ok := tmp.Matches(`/users/123`)
+	require.False(t, ok)
+	_, ok = tmp.Matches(`/users/123`, CaseInsensitiveFixed)
+	require.True(t, ok)
+
+	tmp = MustCreateRfc6570Template(`/users{/id}`, &rejectingVar{})
+	_, ok = tmp.Matches(`/users/123`)
+	require.False(t, ok)
+
+	tmp = MustCreateRfc6570Template(`/users{/id}`)
+	vars, ok := tmp.Matches(`/users/a%2Fb`, PreEncoded)
+	require.True(t, ok)
+	v, _ := vars.Get("id")
+	require.Equal(t, `a%2Fb`, v)
+}
+
+func TestRfc6570Template_ResolveTo(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}/orders{/order_id,x}{?fields}`)
+	tmp2, err := tmp.ResolveTo(Named("id", "a b", "x", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/users/a%20b/orders{/order_id,x}{?fields}`, tmp2.OriginalTemplate())
+
+	_, err = tmp.ResolveTo(Named("id", func() bool {
+		return false
+	}))
+	require.Error(t, err)
+}
+
+func TestRfc6570Template_Sub(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}/`, CaseInsensitiveFixed)
+	tmp2, err := tmp.Sub(`/orders{/order_id}`)
+	require.NoError(t, err)
+	require.Equal(t, `/users{/id}/orders{/order_id}`, tmp2.OriginalTemplate())
+	_, ok := tmp2.Matches(`/USERS/1/ORDERS/2`)
+	require.True(t, ok)
+
+	_, err = tmp.Sub(`{`)
+	require.Error(t, err)
+}
+
+func TestRfc6570Template_Vars(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}{/var:1,var}{?fields*}`)
+	vars := tmp.Vars()
+	require.Equal(t, []PathVar{
+		{Name: "id", Position: 0},
+		{Name: "var", Position: 1},
+		{Name: "var", NamedPosition: 1, Position: 2},
+		{Name: "fields", Position: 3},
+	}, vars)
+}
+
+func TestNewRfc6570Template_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template    string
+		expectErr   string
+		expectedPos int
+	}{
+		{``, `template empty`, 0},
+		{`/foo{bar`, `unclosed '{' at position 4`, 4},
+		{`/foo}bar`, `unopened '}' at position 4`, 4},
+		{`/foo{}`, `expression cannot be empty`, 4},
+		{`/foo{=bar}`, `unsupported expression operator '='`, 5},
+		{`/foo{b ar}`, `invalid variable name 'b ar'`, 4},
+		{`/foo{.bar}{.}`, `invalid variable name ''`, 10},
+		{`/foo{bar:0}`, `invalid prefix modifier`, 4},
+		{`/foo{bar:abc}`, `invalid prefix modifier`, 4},
+		{`/foo{bar%zz}`, `invalid variable name 'bar%zz'`, 4},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.template), func(t *testing.T) {
+			_, err := NewRfc6570Template(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+			sErr, ok := err.(TemplateParseError)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedPos, sErr.Position())
+		})
+	}
+	require.Panics(t, func() {
+		MustCreateRfc6570Template(`{`)
+	})
+}
+
+func TestRfc6570Template_WithRouter(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateRfc6570Template(`/users{/id}`), "rfc"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/me`), "me"))
+	m, ok := r.Match(`/users/me`)
+	require.True(t, ok)
+	require.Equal(t, "me", m.Payload)
+	m, ok = r.Match(`/users/123`)
+	require.True(t, ok)
+	require.Equal(t, "rfc", m.Payload)
+}
+
+type rejectingVar struct{}
+
+func (o *rejectingVar) Match(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) (string, bool) {
+	return value, false
+}
+func (o *rejectingVar) Applicable(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) bool {
+	return true
+}
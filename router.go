@@ -0,0 +1,381 @@
+package urit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewRouter creates a new, empty, Router
+func NewRouter() Router {
+	return &router{
+		root: newRouterNode(),
+	}
+}
+
+// Router is the interface for dispatching paths across many templates
+//
+// Templates added to a router are indexed (by their fixed path parts) so that an incoming path
+// is only split once and only the templates that could possibly match are checked
+//
+// The path is normalized and split using any NormalizeOption or SlashPolicyOption passed to the match methods - templates
+// created with a NormalizeOption or with a CollapseSlashes or AllowEmptySegments policy are not indexed (and are checked
+// after the indexed templates)
+type Router interface {
+	// Add adds a template, with an attached payload, to the router
+	//
+	// returns an error if the template is identical to an already added template (i.e. differs only by var names - so
+	// would always be shadowed by it) - templates that only overlap (e.g. `/users/{id}` and `/users/{id:.*}`) are not
+	// rejected (see Overlaps and Compare)
+	Add(template Template, payload interface{}) error
+	// Match finds the best matching template for the specified path -
+	// and if a successful match, returns the matched template, payload and extracted path vars
+	Match(path string, options ...interface{}) (RouteMatch, bool)
+	// MatchUrl finds the best matching template for the specified URL path -
+	// and if a successful match, returns the matched template, payload and extracted path vars
+	MatchUrl(u url.URL, options ...interface{}) (RouteMatch, bool)
+	// MatchRequest finds the best matching template for the specified request -
+	// and if a successful match, returns the matched template, payload and extracted path vars
+	MatchRequest(req *http.Request, options ...interface{}) (RouteMatch, bool)
+	// Len returns the number of templates added to the router
+	Len() int
+}
+
+// RouteMatch is the result of a successful Router match
+type RouteMatch struct {
+	Template Template
+	Payload  interface{}
+	Vars     PathVars
+}
+
+type router struct {
+	root   *routerNode
+	others []*routerEntry
+	count  int
+	mutex  sync.RWMutex
+}
+
+// Add adds a template, with an attached payload, to the router
+//
+// returns an error if the template is identical to an already added template (i.e. differs only by var names - so
+// would always be shadowed by it) - templates that only overlap (e.g. `/users/{id}` and `/users/{id:.*}`) are not
+// rejected (see Overlaps and Compare) and a template with optional groups is only rejected if it is shadowed for
+// every number of optional groups (e.g. `/users/{id}[/{v}]` is not rejected after `/users/{id}`)
+func (r *router) Add(t Template, payload interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entry := &routerEntry{
+		template: t,
+		payload:  payload,
+	}
+	if rt, ok := t.(*template); ok && rt.slashPolicy&emptySegmentPolicies == 0 && rt.normalize == nil {
+		// templates with optional groups are added at every node where the path can end...
+		nodes := make([]*routerNode, 0, 1)
+		node := r.root
+		permissive := len(rt.fixedMatchOpts) > 0 || len(rt.varMatchOpts) > 0
+		for i, pt := range rt.pathParts {
+			if rt.isGroupBoundary(i) {
+				nodes = append(nodes, node)
+			}
+			node = node.child(pt, permissive)
+		}
+		nodes = append(nodes, node)
+		// the template is only added at the nodes where it is not shadowed (and rejected if shadowed at all)...
+		sig := rt.routeSignature()
+		free := make([]*routerNode, 0, len(nodes))
+		var shadowedBy Template
+		for _, n := range nodes {
+			if st := n.shadowing(sig); st != nil {
+				shadowedBy = st
+			} else {
+				free = append(free, n)
+			}
+		}
+		if len(free) == 0 {
+			return fmt.Errorf("template '%s' is shadowed by template '%s'", t.OriginalTemplate(), shadowedBy.OriginalTemplate())
+		}
+		for _, n := range free {
+			n.addEntry(entry)
+		}
+	} else {
+		r.others = append(r.others, entry)
+	}
+	r.count++
+	return nil
+}
+
+// Match finds the best matching template for the specified path -
+// and if a successful match, returns the matched template, payload and extracted path vars
+func (r *router) Match(path string, options ...interface{}) (RouteMatch, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return RouteMatch{}, false
+	}
+	return r.MatchUrl(*u, options...)
+}
+
+// MatchUrl finds the best matching template for the specified URL path -
+// and if a successful match, returns the matched template, payload and extracted path vars
+func (r *router) MatchUrl(u url.URL, options ...interface{}) (RouteMatch, bool) {
+	return r.first(u, options)
+}
+
+// MatchRequest finds the best matching template for the specified request -
+// and if a successful match, returns the matched template, payload and extracted path vars
+func (r *router) MatchRequest(req *http.Request, options ...interface{}) (RouteMatch, bool) {
+	return r.first(*requestUrl(req), options)
+}
+
+// Len returns the number of templates added to the router
+func (r *router) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.count
+}
+
+func (r *router) first(u url.URL, options []interface{}) (result RouteMatch, ok bool) {
+	r.each(u, options, func(m RouteMatch) bool {
+		result, ok = m, true
+		return true
+	})
+	return
+}
+
+// each calls fn with each matching template in priority order - until fn returns true (or there are no more matches)
+func (r *router) each(u url.URL, options []interface{}, fn func(m RouteMatch) bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	escaped, ok := normalizedPath(u.EscapedPath(), nil, options)
+	var pts []string
+	if ok {
+		pts, ok = splitPathPolicy(escaped, slashPolicy(0, options))
+	}
+	if ok {
+		if pts, ok := decodeSegments(pts, options); ok {
+			candidates := r.root.collect(pts, 0, len(options) > 0, make([]*routerEntry, 0))
+			for _, c := range candidates {
+				ct := c.template.(*template)
+				if !ct.trailingSlashMatches(escaped, ct.slashPolicyFor(options)) {
+					continue
+				}
+				if vars, ok := ct.matchSegments(&u, pts, options); ok && fn(RouteMatch{
+					Template: c.template,
+					Payload:  c.payload,
+					Vars:     vars,
+				}) {
+					return
+				}
+			}
+		}
+	}
+	for _, e := range r.others {
+		if vars, ok := e.template.MatchesUrl(u, options...); ok && fn(RouteMatch{
+			Template: e.template,
+			Payload:  e.payload,
+			Vars:     vars,
+		}) {
+			return
+		}
+	}
+}
+
+// routeSignature is used to distinguish templates with the same path - which can only be distinguished by differing
+// hosts, query sections or (with a strict trailing slash policy) trailing slashes
+func (t *template) routeSignature() string {
+	result := t.host.signature() + querySection(t.queryParts, false)
+	if t.slashPolicy&strictTrailingSlashPolicies != 0 && t.trailingSlash {
+		result += "/"
+	}
+	return result
+}
+
+type routerEntry struct {
+	template Template
+	payload  interface{}
+}
+
+// shadowing returns the template of any entry with the same route signature (i.e. that would shadow a template with
+// the signature) - or nil if there is none
+func (n *routerNode) shadowing(sig string) Template {
+	for _, e := range n.entries {
+		if e.template.(*template).routeSignature() == sig {
+			return e.template
+		}
+	}
+	return nil
+}
+
+func (n *routerNode) addEntry(entry *routerEntry) {
+	n.entries = append(n.entries, entry)
+	sort.SliceStable(n.entries, func(i, j int) bool {
+		if hi, hj := n.entries[i].hasHost(), n.entries[j].hasHost(); hi != hj {
+			return hi
+		} else if qi, qj := n.entries[i].template.(*template).requiredQueryParamsCount(), n.entries[j].template.(*template).requiredQueryParamsCount(); qi != qj {
+			return qi > qj
+		}
+		// a template without optional groups is preferred over one that ends here only by omitting optional groups...
+		return !n.entries[i].template.(*template).hasOptionalGroups() && n.entries[j].template.(*template).hasOptionalGroups()
+	})
+}
+
+func (e *routerEntry) hasHost() bool {
+	rt, ok := e.template.(*template)
+	return ok && rt.host != nil
+}
+
+type routerNode struct {
+	fixed       map[string]*routerNode
+	permissives []*routerEdge
+	vars        []*routerEdge
+	catchAlls   []*routerEdge
+	entries     []*routerEntry
+}
+
+type routerEdge struct {
+	key  string
+	part pathPart
+	rank int
+	node *routerNode
+}
+
+func newRouterNode() *routerNode {
+	return &routerNode{
+		fixed:       map[string]*routerNode{},
+		permissives: make([]*routerEdge, 0),
+		vars:        make([]*routerEdge, 0),
+		catchAlls:   make([]*routerEdge, 0),
+		entries:     make([]*routerEntry, 0),
+	}
+}
+
+// child finds (or adds) the child node for the path part
+//
+// if permissive is specified, fixed path parts are not indexed by value (because match options may
+// alter whether a fixed path part matches) and var path parts are not pre-checked against their regexp
+func (n *routerNode) child(pt pathPart, permissive bool) *routerNode {
+	if pt.fixed && !permissive {
+		value := pt.decodedFixedValue()
+		if cn, ok := n.fixed[value]; ok {
+			return cn
+		}
+		cn := newRouterNode()
+		n.fixed[value] = cn
+		return cn
+	}
+	key := pt.signature()
+	edges := &n.vars
+	if pt.catchAll {
+		edges = &n.catchAlls
+	} else if permissive {
+		key = "~" + key
+		edges = &n.permissives
+	}
+	for _, e := range *edges {
+		if e.key == key {
+			return e.node
+		}
+	}
+	e := &routerEdge{
+		key:  key,
+		part: pt,
+		rank: pt.specificity(),
+		node: newRouterNode(),
+	}
+	*edges = append(*edges, e)
+	sort.SliceStable(*edges, func(i, j int) bool {
+		return (*edges)[i].rank < (*edges)[j].rank
+	})
+	return e.node
+}
+
+// collect collects the candidate entries (in priority order) for the path segments
+func (n *routerNode) collect(pts []string, depth int, hasOptions bool, candidates []*routerEntry) []*routerEntry {
+	if depth == len(pts) {
+		return n.collectCatchAlls(pts, depth, hasOptions, append(candidates, n.entries...))
+	}
+	s := pts[depth]
+	if cn, ok := n.fixed[s]; ok {
+		candidates = cn.collect(pts, depth+1, hasOptions, candidates)
+	}
+	if hasOptions {
+		// match options may alter whether fixed path parts match - so all fixed are candidates...
+		keys := make([]string, 0, len(n.fixed))
+		for k := range n.fixed {
+			if k != s {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			candidates = n.fixed[k].collect(pts, depth+1, hasOptions, candidates)
+		}
+	}
+	for _, e := range n.permissives {
+		candidates = e.node.collect(pts, depth+1, hasOptions, candidates)
+	}
+	for _, e := range n.vars {
+		if hasOptions || e.part.accepts(s) {
+			candidates = e.node.collect(pts, depth+1, hasOptions, candidates)
+		}
+	}
+	return n.collectCatchAlls(pts, depth, hasOptions, candidates)
+}
+
+// collectCatchAlls collects the candidate entries for catch-all parts (that capture all the remaining path segments)
+func (n *routerNode) collectCatchAlls(pts []string, depth int, hasOptions bool, candidates []*routerEntry) []*routerEntry {
+	for _, e := range n.catchAlls {
+		ok := true
+		for i := depth; i < len(pts) && ok && !hasOptions; i++ {
+			ok = e.part.accepts(pts[i])
+		}
+		if ok {
+			candidates = append(candidates, e.node.entries...)
+		}
+	}
+	return candidates
+}
+
+// signature returns a string that identifies the part by what it matches (i.e. disregarding var names)
+func (pt *pathPart) signature() string {
+	if pt.fixed {
+		return `"` + pt.fixedValue + `"`
+	} else if len(pt.subParts) > 0 {
+		var sb strings.Builder
+		for _, sp := range pt.subParts {
+			sb.WriteString(sp.signature())
+		}
+		return sb.String()
+	} else if pt.catchAll {
+		return `{...:` + pt.orgRegexp + `}`
+	}
+	return `{:` + pt.orgRegexp + `}`
+}
+
+// specificity returns the ranking of the part (lower is more specific)
+func (pt *pathPart) specificity() int {
+	if pt.fixed {
+		return 0
+	} else if len(pt.subParts) > 0 {
+		return 1
+	} else if pt.catchAll && pt.regexp != nil {
+		return 4
+	} else if pt.catchAll {
+		return 5
+	} else if pt.regexp != nil {
+		return 2
+	}
+	return 3
+}
+
+// accepts is a pre-check as to whether the path part could match the value
+func (pt *pathPart) accepts(s string) bool {
+	if pt.fixed {
+		return pt.fixedValue == s
+	} else if len(pt.subParts) > 0 {
+		return pt.allRegexp == nil || pt.allRegexp.MatchString(s)
+	}
+	return pt.regexp == nil || pt.regexp.MatchString(s)
+}
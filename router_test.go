@@ -0,0 +1,180 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewRouter(t *testing.T) {
+	r := NewRouter()
+	require.NotNil(t, r)
+	require.Equal(t, 0, r.Len())
+	_, ok := r.Match(`/foo`)
+	require.False(t, ok)
+}
+
+func TestRouter_Match(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/users`), "users"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}`), "user"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/me`), "me"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id:[0-9]+}`), "user-num"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}/orders/{order-id}`), "user-order"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}/{year:[0-9]{4}}-{month:[0-9]{2}}`), "user-month"))
+	require.Equal(t, 6, r.Len())
+
+	testCases := []struct {
+		path          string
+		expectOk      bool
+		expectPayload string
+		expectVars    map[string]string
+	}{
+		{
+			path:          `/users`,
+			expectOk:      true,
+			expectPayload: "users",
+		},
+		{
+			path:          `/users/me`,
+			expectOk:      true,
+			expectPayload: "me",
+		},
+		{
+			path:          `/users/123`,
+			expectOk:      true,
+			expectPayload: "user-num",
+			expectVars:    map[string]string{"id": "123"},
+		},
+		{
+			path:          `/users/abc`,
+			expectOk:      true,
+			expectPayload: "user",
+			expectVars:    map[string]string{"id": "abc"},
+		},
+		{
+			path:          `https://www.example.com/users/abc/orders/xyz?foo=bar`,
+			expectOk:      true,
+			expectPayload: "user-order",
+			expectVars:    map[string]string{"id": "abc", "order-id": "xyz"},
+		},
+		{
+			path:          `/users/abc/2022-11`,
+			expectOk:      true,
+			expectPayload: "user-month",
+			expectVars:    map[string]string{"id": "abc", "year": "2022", "month": "11"},
+		},
+		{
+			path: `/users/abc/2022-1`,
+		},
+		{
+			path: `/users/abc/orders`,
+		},
+		{
+			path: `/foo`,
+		},
+		{
+			path: `/`,
+		},
+		{
+			path: `://my.org`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			m, ok := r.Match(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			if tc.expectOk {
+				require.Equal(t, tc.expectPayload, m.Payload)
+				require.NotNil(t, m.Template)
+				require.Equal(t, len(tc.expectVars), m.Vars.Len())
+				for k, v := range tc.expectVars {
+					av, ok := m.Vars.Get(k)
+					require.True(t, ok)
+					require.Equal(t, v, av)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_MatchUrlAndRequest(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/credits/?/?`), "credits"))
+
+	u, _ := url.Parse(`https://www.example.com/credits/2022/11`)
+	m, ok := r.MatchUrl(*u)
+	require.True(t, ok)
+	require.Equal(t, "credits", m.Payload)
+	v, ok := m.Vars.Get(1)
+	require.True(t, ok)
+	require.Equal(t, "11", v)
+
+	req, err := http.NewRequest(`GET`, `https://www.example.com/credits/2022/11`, nil)
+	require.NoError(t, err)
+	m, ok = r.MatchRequest(req)
+	require.True(t, ok)
+	require.Equal(t, "credits", m.Payload)
+	v, ok = m.Vars.Get(0)
+	require.True(t, ok)
+	require.Equal(t, "2022", v)
+}
+
+func TestRouter_MatchWithOptions(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/foo/{id}/bar`), "foo"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/baz/{id}/qux`, CaseInsensitiveFixed), "baz"))
+
+	_, ok := r.Match(`/FOO/1/BAR`)
+	require.False(t, ok)
+	m, ok := r.Match(`/FOO/1/BAR`, CaseInsensitiveFixed)
+	require.True(t, ok)
+	require.Equal(t, "foo", m.Payload)
+
+	m, ok = r.Match(`/BAZ/1/Qux`)
+	require.True(t, ok)
+	require.Equal(t, "baz", m.Payload)
+}
+
+func TestRouter_AddShadowed(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id}`), nil))
+	err := r.Add(MustCreateTemplate(`/users/{user-id}`), nil)
+	require.Error(t, err)
+	require.Equal(t, `template '/users/{user-id}' is shadowed by template '/users/{id}'`, err.Error())
+	require.Equal(t, 1, r.Len())
+
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id:[a-z]+}`), nil))
+	err = r.Add(MustCreateTemplate(`/users/{user-id:[a-z]+}`), nil)
+	require.Error(t, err)
+	err = r.Add(MustCreateTemplate(`/users/?`), nil)
+	require.Error(t, err)
+	require.Equal(t, 2, r.Len())
+
+	// overlapping (but not identical) templates are not rejected - the more specific is matched first...
+	require.NoError(t, r.Add(MustCreateTemplate(`/users/{id:.*}`), "any"))
+	require.Equal(t, 3, r.Len())
+	m, ok := r.Match(`/users/abc`)
+	require.True(t, ok)
+	require.Equal(t, `/users/{id:[a-z]+}`, m.Template.OriginalTemplate())
+}
+
+func TestRouter_OtherTemplateImplementations(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(&wrappedTemplate{MustCreateTemplate(`/foo/{id}`).(*template)}, "wrapped"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/foo/bar`), "bar"))
+
+	m, ok := r.Match(`/foo/bar`)
+	require.True(t, ok)
+	require.Equal(t, "bar", m.Payload)
+	m, ok = r.Match(`/foo/baz`)
+	require.True(t, ok)
+	require.Equal(t, "wrapped", m.Payload)
+	_, ok = r.Match(`/foo`)
+	require.False(t, ok)
+}
+
+type wrappedTemplate struct {
+	*template
+}
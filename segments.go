@@ -0,0 +1,119 @@
+package urit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Segments is the value type of a catch-all path var (e.g. `/static/{path...}` or `/proxy/*`) - the
+// remaining path segments captured by the catch-all
+//
+// When getting a catch-all var as a string (e.g. using PathVars.Get) the segments are joined with "/" - use
+// PathVars.GetSegments to get the individual segments
+type Segments []string
+
+// String returns the segments joined with "/"
+func (s Segments) String() string {
+	return strings.Join(s, "/")
+}
+
+// catchAllName is the var name used for an unnamed catch-all (i.e. `*`)
+const catchAllName = "*"
+
+// matchCatchAll matches the remaining path segments against the catch-all path part
+func (pt *pathPart) matchCatchAll(pts []string, pathPos int, vars PathVars, vOpts varMatchOptions) bool {
+	if pt.regexp != nil {
+		for _, s := range pts {
+			if !pt.regexp.MatchString(s) {
+				return false
+			}
+		}
+	}
+	value := append(Segments{}, pts...)
+	if len(vOpts) > 0 {
+		joined := value.String()
+		if rs, vok, applicable := vOpts.check(joined, vars.Len(), pt.name, pt.regexp, pt.orgRegexp, pathPos, vars); applicable {
+			if !vok {
+				return false
+			} else if rs != joined {
+				value = strings.Split(rs, "/")
+			}
+		}
+	}
+	pt.addFound(vars, value)
+	return true
+}
+
+// catchAllFrom generates the path for the catch-all path part
+//
+// the var value can be a string (which is split on "/" - and each segment encoded) or a slice (where each item is encoded as a segment)
+func (tr *positionsTracker) catchAllFrom(pt *pathPart) (string, error) {
+	v, ok := tr.getRawVar(pt)
+	if !ok || v == nil {
+		return "", nil
+	}
+	var segs []string
+	switch av := v.(type) {
+	case Segments:
+		segs = av
+	case []string:
+		segs = av
+	case []interface{}:
+		segs = make([]string, 0, len(av))
+		for _, item := range av {
+			str, err := getValue(item)
+			if err != nil {
+				return "", err
+			}
+			segs = append(segs, str)
+		}
+	default:
+		str, err := getValue(v)
+		if err != nil {
+			return "", err
+		}
+		if str = strings.Trim(str, "/"); str != "" {
+			segs = strings.Split(str, "/")
+		}
+	}
+	var sb strings.Builder
+	for _, s := range segs {
+		sb.WriteString("/" + tr.encode(s))
+	}
+	return sb.String(), nil
+}
+
+// getRawVar gets the raw var value (i.e. not converted to a string) for the path part
+func (tr *positionsTracker) getRawVar(pt *pathPart) (interface{}, bool) {
+	if tr.vars == nil {
+		return nil, false
+	} else if tr.vars.VarsType() == Positions {
+		if all := tr.vars.GetAll(); tr.varPosition < len(all) {
+			tr.varPosition++
+			return all[tr.varPosition-1].Value, true
+		}
+		return nil, false
+	}
+	np := tr.namedPositions[pt.name]
+	for _, v := range tr.vars.GetAll() {
+		if v.Name == pt.name && v.NamedPosition == np {
+			tr.namedPositions[pt.name] = np + 1
+			tr.varPosition++
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (pt *pathPart) catchAllString() string {
+	if pt.name == catchAllName {
+		return catchAllName
+	} else if pt.orgRegexp != "" {
+		return fmt.Sprintf("{%s...:%s}", pt.name, pt.orgRegexp)
+	}
+	return "{" + pt.name + "...}"
+}
+
+func (t *template) hasCatchAll() bool {
+	return len(t.pathParts) > 0 && t.pathParts[len(t.pathParts)-1].catchAll
+}
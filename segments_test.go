@@ -0,0 +1,199 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTemplate_CatchAll_Parse(t *testing.T) {
+	testCases := []struct {
+		template     string
+		expectName   string
+		expectNoPats string
+	}{
+		{`/static/{path...}`, "path", `/static/{path...}`},
+		{`/static/{path...:[a-z.]+}`, "path", `/static/{path...}`},
+		{`/proxy/*`, "*", `/proxy/*`},
+		{`/files/{id}/{rest...}{?download}`, "rest", `/files/{id}/{rest...}{?download}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmp, err := NewTemplate(tc.template)
+			require.NoError(t, err)
+			rt := tmp.(*template)
+			require.True(t, rt.hasCatchAll())
+			require.Equal(t, tc.expectName, rt.pathParts[len(rt.pathParts)-1].name)
+			require.Equal(t, tc.template, tmp.OriginalTemplate())
+			require.Equal(t, tc.expectNoPats, tmp.Template(true))
+		})
+	}
+}
+
+func TestTemplate_CatchAll_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template  string
+		expectErr string
+	}{
+		{`/static/{path...}/more`, `catch-all var must be the last path segment`},
+		{`/proxy/*/more`, `catch-all var must be the last path segment`},
+		{`/static/x-{path...}`, `catch-all var must be a whole path segment`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewTemplate(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestTemplate_CatchAll_Matches(t *testing.T) {
+	testCases := []struct {
+		template     string
+		path         string
+		options      []interface{}
+		expectOk     bool
+		expectValue  string
+		expectSegs   Segments
+		expectVarLen int
+	}{
+		{`/static/{path...}`, `/static/css/site.css`, nil, true, `css/site.css`, Segments{"css", "site.css"}, 1},
+		{`/static/{path...}`, `/static/site.css`, nil, true, `site.css`, Segments{"site.css"}, 1},
+		{`/static/{path...}`, `/static`, nil, true, ``, Segments{}, 1},
+		{`/static/{path...}`, `/other/site.css`, nil, false, ``, nil, 0},
+		{`/static/{path...:[a-z.]+}`, `/static/css/site.css`, nil, true, `css/site.css`, Segments{"css", "site.css"}, 1},
+		{`/static/{path...:[a-z.]+}`, `/static/css/site2.css`, nil, false, ``, nil, 0},
+		{`/static/{path...}`, `/static/a%20b/c`, nil, true, `a b/c`, Segments{"a b", "c"}, 1},
+		{`/static/{path...}`, `/static/a%20b/c`, []interface{}{PreEncoded}, true, `a%20b/c`, Segments{"a%20b", "c"}, 1},
+		{`/proxy/*`, `/proxy/a/b/c`, nil, true, `a/b/c`, Segments{"a", "b", "c"}, 1},
+		{`/files/{id}/{rest...}`, `/files/1/a/b`, nil, true, `a/b`, Segments{"a", "b"}, 2},
+		{`/static/{path...}`, `/static/a/b`, []interface{}{&rejectingVar{}}, false, ``, nil, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path, tc.options...)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				require.Equal(t, tc.expectVarLen, vars.Len())
+				name := tmp.(*template).pathParts[len(tmp.(*template).pathParts)-1].name
+				v, ok := vars.Get(name)
+				require.True(t, ok)
+				require.Equal(t, tc.expectValue, v)
+				segs, ok := vars.GetSegments(name)
+				require.True(t, ok)
+				require.Equal(t, tc.expectSegs, segs)
+			}
+		})
+	}
+}
+
+func TestTemplate_CatchAll_PathFrom(t *testing.T) {
+	tmp := MustCreateTemplate(`/static/{path...}`)
+	pth, err := tmp.PathFrom(Named("path", "css/site.css"))
+	require.NoError(t, err)
+	require.Equal(t, `/static/css/site.css`, pth)
+	pth, err = tmp.PathFrom(Named("path", "/css/site.css/"))
+	require.NoError(t, err)
+	require.Equal(t, `/static/css/site.css`, pth)
+	pth, err = tmp.PathFrom(Named("path", []string{"a b", "c/d"}))
+	require.NoError(t, err)
+	require.Equal(t, `/static/a%20b/c%2Fd`, pth)
+	pth, err = tmp.PathFrom(Named("path", Segments{"a", "b"}))
+	require.NoError(t, err)
+	require.Equal(t, `/static/a/b`, pth)
+	pth, err = tmp.PathFrom(Named("path", []interface{}{"a", 1}))
+	require.NoError(t, err)
+	require.Equal(t, `/static/a/1`, pth)
+	pth, err = tmp.PathFrom(Named("path", "a%20b/c"), PreEncoded)
+	require.NoError(t, err)
+	require.Equal(t, `/static/a%20b/c`, pth)
+	pth, err = tmp.PathFrom(nil)
+	require.NoError(t, err)
+	require.Equal(t, `/static`, pth)
+	_, err = tmp.PathFrom(Named("path", []interface{}{func() {}}))
+	require.Error(t, err)
+
+	tmp = MustCreateTemplate(`/proxy/*`)
+	pth, err = tmp.PathFrom(Named("*", "a/b"))
+	require.NoError(t, err)
+	require.Equal(t, `/proxy/a/b`, pth)
+
+	// round trip...
+	tmp = MustCreateTemplate(`/files/{id}/{rest...}`)
+	vars, ok := tmp.Matches(`/files/1/a/b/c`)
+	require.True(t, ok)
+	pth, err = tmp.PathFrom(vars)
+	require.NoError(t, err)
+	require.Equal(t, `/files/1/a/b/c`, pth)
+}
+
+func TestTemplate_CatchAll_Vars(t *testing.T) {
+	tmp := MustCreateTemplate(`/files/{id}/{rest...}`)
+	require.Equal(t, []PathVar{
+		{Name: "id", Position: 0},
+		{Name: "rest", Position: 1, Optional: true},
+	}, tmp.Vars())
+
+	vars, ok := tmp.Matches(`/files/1/a/b`)
+	require.True(t, ok)
+	segs, ok := vars.GetSegments("id")
+	require.True(t, ok)
+	require.Equal(t, Segments{"1"}, segs)
+	_, ok = vars.GetSegments("unknown")
+	require.False(t, ok)
+
+	dst := struct {
+		Id   int      `urit:"id"`
+		Rest []string `urit:"rest"`
+		Path string   `urit:"rest"`
+	}{}
+	require.NoError(t, vars.Bind(&dst))
+	require.Equal(t, 1, dst.Id)
+	require.Equal(t, []string{"a", "b"}, dst.Rest)
+	require.Equal(t, "a/b", dst.Path)
+}
+
+func TestTemplate_CatchAll_SubAndResolveTo(t *testing.T) {
+	tmp := MustCreateTemplate(`/files/{id}/{rest...}`)
+	_, err := tmp.Sub(`/more`)
+	require.Error(t, err)
+	require.Equal(t, `cannot add sub-path to template with catch-all var`, err.Error())
+
+	tmp2, err := tmp.ResolveTo(Named("id", 1, "rest", "a/b"))
+	require.NoError(t, err)
+	require.Equal(t, `/files/1/{rest...}`, tmp2.OriginalTemplate())
+	vars, ok := tmp2.Matches(`/files/1/x/y`)
+	require.True(t, ok)
+	v, _ := vars.Get("rest")
+	require.Equal(t, "x/y", v)
+}
+
+func TestRouter_CatchAll(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/static/{path...}`), "static"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/static/{path...:[a-z]+}`), "lower"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/static/favicon.ico`), "favicon"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/static/{dir}/index.html`), "index"))
+	err := r.Add(MustCreateTemplate(`/static/{other...}`), nil)
+	require.Error(t, err)
+	require.Equal(t, `template '/static/{other...}' is shadowed by template '/static/{path...}'`, err.Error())
+
+	m, ok := r.Match(`/static/favicon.ico`)
+	require.True(t, ok)
+	require.Equal(t, "favicon", m.Payload)
+	m, ok = r.Match(`/static/css/index.html`)
+	require.True(t, ok)
+	require.Equal(t, "index", m.Payload)
+	m, ok = r.Match(`/static/css/site`)
+	require.True(t, ok)
+	require.Equal(t, "lower", m.Payload)
+	m, ok = r.Match(`/static/css/site.css`)
+	require.True(t, ok)
+	require.Equal(t, "static", m.Payload)
+	m, ok = r.Match(`/static`)
+	require.True(t, ok)
+	require.Equal(t, "lower", m.Payload)
+	_, ok = r.Match(`/other`)
+	require.False(t, ok)
+}
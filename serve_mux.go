@@ -0,0 +1,275 @@
+package urit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewServeMux creates a new ServeMux
+func NewServeMux() ServeMux {
+	return &serveMux{
+		router: NewRouter().(*router),
+		routes: map[string]*muxRoute{},
+	}
+}
+
+// ServeMux is a http.Handler that dispatches requests to handlers registered by template and method
+//
+// The path vars extracted from the request path are put into the request context - and
+// can be retrieved in the handler using PathVarsFromContext
+//
+// If no template matches the request path, the not found handler is called (default http.NotFound) - if
+// a template matches the request path but no handler is registered for the request method, the method not allowed
+// handler is called (default responds 405 Method Not Allowed, with an Allow header listing the registered methods)
+type ServeMux interface {
+	http.Handler
+	// Handle registers the handler for the given method and template
+	//
+	// an empty method registers the handler for any method - returns an error if a template with the same original
+	// template has already been registered (for another method) with different options
+	Handle(method string, template Template, handler http.Handler) error
+	// HandleFunc registers the handler function for the given method and template
+	//
+	// an empty method registers the handler function for any method
+	HandleFunc(method string, template Template, handler func(http.ResponseWriter, *http.Request)) error
+	// NotFound sets the handler used when no template matches the request path
+	NotFound(handler http.Handler) ServeMux
+	// MethodNotAllowed sets the handler used when a template matches the request path but has no handler for the request method
+	MethodNotAllowed(handler http.Handler) ServeMux
+	// Mount mounts the handler (e.g. another ServeMux) under the prefix template - requests (for any method) whose path
+	// starts with the prefix are passed to the handler with the prefix stripped from the request URL path
+	//
+	// the path vars extracted by the prefix are put into the request context (and a mounted ServeMux merges them
+	// with the path vars it extracts) - the prefix cannot have positional vars, optional groups or a catch-all var
+	Mount(prefix Template, handler http.Handler) error
+}
+
+// PathVarsFromContext returns the path vars (put into the request context by ServeMux)
+func PathVarsFromContext(ctx context.Context) (PathVars, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	vars, ok := ctx.Value(pathVarsContextKey{}).(PathVars)
+	return vars, ok
+}
+
+type pathVarsContextKey struct{}
+
+type serveMux struct {
+	router           *router
+	routes           map[string]*muxRoute
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+	mutex            sync.RWMutex
+}
+
+type muxRoute struct {
+	template Template
+	handlers map[string]http.Handler
+}
+
+// sameTemplate determines whether the template (with the same original template as the route template) is the same
+// type and has the same options as the route template - so that the handlers for all methods match the same
+func (r *muxRoute) sameTemplate(t Template) bool {
+	return reflect.TypeOf(t) == reflect.TypeOf(r.template) && reflect.DeepEqual(t.Options(), r.template.Options())
+}
+
+// Handle registers the handler for the given method and template
+//
+// an empty method registers the handler for any method - returns an error if a template with the same original
+// template has already been registered (for another method) with different options
+func (m *serveMux) Handle(method string, template Template, handler http.Handler) error {
+	if template == nil {
+		return errors.New("template cannot be nil")
+	} else if handler == nil {
+		return errors.New("handler cannot be nil")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	method = strings.ToUpper(method)
+	key := template.OriginalTemplate()
+	if route, ok := m.routes[key]; ok {
+		if !route.sameTemplate(template) {
+			return fmt.Errorf("template '%s' already registered with different options", key)
+		} else if _, exists := route.handlers[method]; exists {
+			return fmt.Errorf("handler already registered for method '%s' and template '%s'", method, key)
+		}
+		route.handlers[method] = handler
+		return nil
+	}
+	route := &muxRoute{
+		template: template,
+		handlers: map[string]http.Handler{method: handler},
+	}
+	if err := m.router.Add(template, route); err != nil {
+		return err
+	}
+	m.routes[key] = route
+	return nil
+}
+
+// HandleFunc registers the handler function for the given method and template
+//
+// an empty method registers the handler function for any method
+func (m *serveMux) HandleFunc(method string, template Template, handler func(http.ResponseWriter, *http.Request)) error {
+	if handler == nil {
+		return errors.New("handler cannot be nil")
+	}
+	return m.Handle(method, template, http.HandlerFunc(handler))
+}
+
+// NotFound sets the handler used when no template matches the request path
+func (m *serveMux) NotFound(handler http.Handler) ServeMux {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.notFound = handler
+	return m
+}
+
+// MethodNotAllowed sets the handler used when a template matches the request path but has no handler for the request method
+func (m *serveMux) MethodNotAllowed(handler http.Handler) ServeMux {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.methodNotAllowed = handler
+	return m
+}
+
+// Mount mounts the handler (e.g. another ServeMux) under the prefix template - requests (for any method) whose path
+// starts with the prefix are passed to the handler with the prefix stripped from the request URL path
+//
+// the path vars extracted by the prefix are put into the request context (and a mounted ServeMux merges them
+// with the path vars it extracts) - the prefix cannot have positional vars, optional groups or a catch-all var
+func (m *serveMux) Mount(prefix Template, handler http.Handler) error {
+	if prefix == nil {
+		return errors.New("template cannot be nil")
+	} else if handler == nil {
+		return errors.New("handler cannot be nil")
+	} else if _, ok := prefix.(*template); !ok {
+		return fmt.Errorf("cannot mount under template '%s'", prefix.OriginalTemplate())
+	}
+	t, err := prefix.Sub("/" + catchAllName)
+	if err != nil {
+		return err
+	}
+	return m.Handle("", t, &mountHandler{prefix: prefix, handler: handler})
+}
+
+type mountHandler struct {
+	prefix  Template
+	handler http.Handler
+}
+
+func (h *mountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars, remainder, ok := h.prefix.MatchesPrefixRequest(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if outer, ok := PathVarsFromContext(r.Context()); ok {
+		vars = mergePathVars(outer, vars)
+	}
+	u := *r.URL
+	u.RawPath = remainder
+	if u.Path, ok = _PercentEncoded.Decode(remainder); !ok || u.Path == remainder {
+		u.Path, u.RawPath = remainder, ""
+	}
+	r2 := r.WithContext(context.WithValue(r.Context(), pathVarsContextKey{}, vars))
+	r2.URL = &u
+	h.handler.ServeHTTP(w, r2)
+}
+
+func (m *serveMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mutex.RLock()
+	var handler http.Handler
+	var vars PathVars
+	var unhandled []*muxRoute
+	m.router.each(*requestUrl(r), nil, func(match RouteMatch) bool {
+		route := match.Payload.(*muxRoute)
+		if handler = route.handlerFor(r.Method); handler != nil {
+			vars = match.Vars
+			return true
+		}
+		unhandled = append(unhandled, route)
+		return false
+	})
+	notFound, methodNotAllowed := m.notFound, m.methodNotAllowed
+	m.mutex.RUnlock()
+	if _, ok := handler.(*mountHandler); ok {
+		// mount handlers put the prefix path vars into the request context...
+		handler.ServeHTTP(w, r)
+	} else if handler != nil {
+		if outer, ok := PathVarsFromContext(r.Context()); ok {
+			vars = mergePathVars(outer, vars)
+		}
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pathVarsContextKey{}, vars)))
+	} else if len(unhandled) == 0 {
+		if notFound == nil {
+			notFound = http.HandlerFunc(http.NotFound)
+		}
+		notFound.ServeHTTP(w, r)
+	} else {
+		w.Header().Set("Allow", allowedMethods(unhandled))
+		if methodNotAllowed == nil {
+			methodNotAllowed = http.HandlerFunc(defaultMethodNotAllowed)
+		}
+		methodNotAllowed.ServeHTTP(w, r)
+	}
+}
+
+// allowedMethods returns the (sorted, comma separated) methods registered for the routes
+func allowedMethods(routes []*muxRoute) string {
+	allowed := map[string]bool{}
+	for _, route := range routes {
+		for method := range route.handlers {
+			allowed[method] = true
+		}
+	}
+	if allowed[http.MethodGet] {
+		allowed[http.MethodHead] = true
+	}
+	methods := make([]string, 0, len(allowed))
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// mergePathVars merges the path vars from a mounting ServeMux with the path vars matched by a mounted ServeMux
+// (if the vars types differ, only the matched path vars are used)
+func mergePathVars(outer PathVars, inner PathVars) PathVars {
+	if outer == nil || outer.Len() == 0 || inner == nil || outer.VarsType() != inner.VarsType() {
+		return inner
+	}
+	result := newPathVars(inner.VarsType())
+	for _, vars := range []PathVars{outer, inner} {
+		for _, pv := range vars.GetAll() {
+			if result.VarsType() == Names {
+				_ = result.AddNamedValue(pv.Name, pv.Value)
+			} else {
+				_ = result.AddPositionalValue(pv.Value)
+			}
+			setLastTypedValue(result, pv.TypedValue)
+		}
+	}
+	return result
+}
+
+func (r *muxRoute) handlerFor(method string) http.Handler {
+	if h, ok := r.handlers[method]; ok {
+		return h
+	} else if h, ok = r.handlers[http.MethodGet]; ok && method == http.MethodHead {
+		return h
+	}
+	return r.handlers[""]
+}
+
+func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,294 @@
+package urit
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestServeMux(t *testing.T) {
+	mux := NewServeMux()
+	require.NoError(t, mux.HandleFunc(http.MethodGet, MustCreateTemplate(`/users/{id}`), func(w http.ResponseWriter, r *http.Request) {
+		vars, ok := PathVarsFromContext(r.Context())
+		require.True(t, ok)
+		id, _ := vars.Get("id")
+		_, _ = w.Write([]byte("get user " + id))
+	}))
+	require.NoError(t, mux.HandleFunc(http.MethodPut, MustCreateTemplate(`/users/{id}`), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("put user"))
+	}))
+	require.NoError(t, mux.HandleFunc(http.MethodDelete, MustCreateTemplate(`/users/me`), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("delete me"))
+	}))
+	require.NoError(t, mux.HandleFunc("", MustCreateTemplate(`/any`), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("any " + r.Method))
+	}))
+
+	testCases := []struct {
+		method       string
+		path         string
+		expectStatus int
+		expectBody   string
+		expectAllow  string
+	}{
+		{
+			method:       http.MethodGet,
+			path:         `/users/123`,
+			expectStatus: http.StatusOK,
+			expectBody:   "get user 123",
+		},
+		{
+			method:       http.MethodHead,
+			path:         `/users/123`,
+			expectStatus: http.StatusOK,
+			expectBody:   "get user 123",
+		},
+		{
+			method:       http.MethodPut,
+			path:         `/users/123`,
+			expectStatus: http.StatusOK,
+			expectBody:   "put user",
+		},
+		{
+			method:       http.MethodGet,
+			path:         `/users/me`,
+			expectStatus: http.StatusOK,
+			expectBody:   "get user me",
+		},
+		{
+			method:       http.MethodDelete,
+			path:         `/users/me`,
+			expectStatus: http.StatusOK,
+			expectBody:   "delete me",
+		},
+		{
+			method:       http.MethodPost,
+			path:         `/users/me`,
+			expectStatus: http.StatusMethodNotAllowed,
+			expectBody:   "Method Not Allowed\n",
+			expectAllow:  "DELETE, GET, HEAD, PUT",
+		},
+		{
+			method:       http.MethodDelete,
+			path:         `/users/123`,
+			expectStatus: http.StatusMethodNotAllowed,
+			expectBody:   "Method Not Allowed\n",
+			expectAllow:  "GET, HEAD, PUT",
+		},
+		{
+			method:       http.MethodPatch,
+			path:         `/any`,
+			expectStatus: http.StatusOK,
+			expectBody:   "any PATCH",
+		},
+		{
+			method:       http.MethodGet,
+			path:         `/users`,
+			expectStatus: http.StatusNotFound,
+			expectBody:   "404 page not found\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, tc.expectStatus, w.Code)
+			require.Equal(t, tc.expectBody, w.Body.String())
+			require.Equal(t, tc.expectAllow, w.Header().Get("Allow"))
+		})
+	}
+}
+
+func TestServeMux_CustomHandlers(t *testing.T) {
+	mux := NewServeMux().
+		NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})).
+		MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}))
+	require.NoError(t, mux.HandleFunc(http.MethodGet, MustCreateTemplate(`/foo`), func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/bar`, nil))
+	require.Equal(t, http.StatusTeapot, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, `/foo`, nil))
+	require.Equal(t, http.StatusConflict, w.Code)
+	require.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+}
+
+func TestServeMux_HostTemplates(t *testing.T) {
+	mux := NewServeMux()
+	tmp := MustCreateTemplate(`https://{tenant}.example.com/users/{id}`)
+	require.NoError(t, mux.HandleFunc(http.MethodGet, tmp, func(w http.ResponseWriter, r *http.Request) {
+		vars, _ := PathVarsFromContext(r.Context())
+		tenant, _ := vars.Get("tenant")
+		id, _ := vars.Get("id")
+		_, _ = w.Write([]byte(tenant + " user " + id))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, `/users/123`, nil)
+	req.Host = "acme.example.com"
+	req.TLS = &tls.ConnectionState{}
+	_, ok := tmp.MatchesRequest(req)
+	require.True(t, ok)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "acme user 123", w.Body.String())
+
+	// not https...
+	req = httptest.NewRequest(http.MethodGet, `/users/123`, nil)
+	req.Host = "acme.example.com"
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServeMux_HandleErrors(t *testing.T) {
+	mux := NewServeMux()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	err := mux.Handle(http.MethodGet, nil, h)
+	require.Error(t, err)
+	require.Equal(t, `template cannot be nil`, err.Error())
+	err = mux.Handle(http.MethodGet, MustCreateTemplate(`/foo`), nil)
+	require.Error(t, err)
+	require.Equal(t, `handler cannot be nil`, err.Error())
+	err = mux.HandleFunc(http.MethodGet, MustCreateTemplate(`/foo`), nil)
+	require.Error(t, err)
+	require.Equal(t, `handler cannot be nil`, err.Error())
+
+	require.NoError(t, mux.Handle(http.MethodGet, MustCreateTemplate(`/foo/{id}`), h))
+	err = mux.Handle("get", MustCreateTemplate(`/foo/{id}`), h)
+	require.Error(t, err)
+	require.Equal(t, `handler already registered for method 'GET' and template '/foo/{id}'`, err.Error())
+	err = mux.Handle(http.MethodPost, MustCreateTemplate(`/foo/{foo-id}`), h)
+	require.Error(t, err)
+	require.Equal(t, `template '/foo/{foo-id}' is shadowed by template '/foo/{id}'`, err.Error())
+}
+
+func TestServeMux_HandleDifferentOptions(t *testing.T) {
+	mux := NewServeMux()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	require.NoError(t, mux.Handle(http.MethodGet, MustCreateTemplate(`/foo/{id}`, CaseInsensitiveFixed), h))
+	require.NoError(t, mux.Handle(http.MethodPut, MustCreateTemplate(`/foo/{id}`, CaseInsensitiveFixed), h))
+	err := mux.Handle(http.MethodPost, MustCreateTemplate(`/foo/{id}`), h)
+	require.Error(t, err)
+	require.Equal(t, `template '/foo/{id}' already registered with different options`, err.Error())
+	err = mux.Handle(http.MethodPost, MustCreateRfc6570Template(`/foo/{id}`), h)
+	require.Error(t, err)
+	require.Equal(t, `template '/foo/{id}' already registered with different options`, err.Error())
+}
+
+func TestServeMux_StopsAtFirstHandled(t *testing.T) {
+	mux := NewServeMux()
+	require.NoError(t, mux.HandleFunc(http.MethodGet, MustCreateTemplate(`/foo/bar`), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("bar"))
+	}))
+	counting := &countingTemplate{baseTemplate: MustCreateRfc6570Template(`/foo/{id}`)}
+	require.NoError(t, mux.HandleFunc(http.MethodPost, counting, func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/foo/bar`, nil))
+	require.Equal(t, "bar", w.Body.String())
+	require.Equal(t, 0, counting.count)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, `/foo/bar`, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	require.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+	require.Equal(t, 1, counting.count)
+}
+
+type baseTemplate = Template
+
+type countingTemplate struct {
+	baseTemplate
+	count int
+}
+
+func (t *countingTemplate) MatchesUrl(u url.URL, options ...interface{}) (PathVars, bool) {
+	t.count++
+	return t.baseTemplate.MatchesUrl(u, options...)
+}
+
+func TestPathVarsFromContext(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, `/foo`, nil)
+	_, ok := PathVarsFromContext(req.Context())
+	require.False(t, ok)
+	_, ok = PathVarsFromContext(nil)
+	require.False(t, ok)
+}
+
+func TestServeMux_Mount(t *testing.T) {
+	orders := NewServeMux()
+	require.NoError(t, orders.HandleFunc(http.MethodGet, MustCreateTemplate(`/orders/{id}`), func(w http.ResponseWriter, r *http.Request) {
+		vars, _ := PathVarsFromContext(r.Context())
+		version, _ := vars.Get("version")
+		id, _ := vars.Get("id")
+		_, _ = w.Write([]byte(r.URL.Path + " " + version + " " + id))
+	}))
+	mux := NewServeMux()
+	require.NoError(t, mux.Mount(MustCreateTemplate(`/api/{version}`), orders))
+	require.NoError(t, mux.Mount(MustCreateTemplate(`/raw`), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + " " + r.URL.EscapedPath()))
+	})))
+	require.NoError(t, mux.HandleFunc(http.MethodGet, MustCreateTemplate(`/api/{version}/status`), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("status"))
+	}))
+
+	testCases := []struct {
+		path         string
+		expectStatus int
+		expectBody   string
+	}{
+		{`/api/v1/orders/123`, http.StatusOK, "/orders/123 v1 123"},
+		{`/api/v1/status`, http.StatusOK, "status"},
+		{`/api/v1/other`, http.StatusNotFound, "404 page not found\n"},
+		{`/raw/a%2Fb/c`, http.StatusOK, "/a/b/c /a%2Fb/c"},
+		{`/raw`, http.StatusOK, "/ /"},
+		{`/other`, http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, tc.expectStatus, w.Code)
+			require.Equal(t, tc.expectBody, w.Body.String())
+		})
+	}
+
+	require.Error(t, mux.Mount(nil, orders))
+	require.Error(t, mux.Mount(MustCreateTemplate(`/foo`), nil))
+	require.Error(t, mux.Mount(MustCreateRfc6570Template(`/foo`), orders))
+	require.Error(t, mux.Mount(MustCreateTemplate(`/foo/?`), orders))
+	require.Error(t, mux.Mount(MustCreateTemplate(`/foo[/{id}]`), orders))
+}
+
+func TestServeMux_Mount_Nested(t *testing.T) {
+	items := NewServeMux()
+	require.NoError(t, items.HandleFunc(http.MethodGet, MustCreateTemplate(`/items/{item}`), func(w http.ResponseWriter, r *http.Request) {
+		vars, _ := PathVarsFromContext(r.Context())
+		tenant, _ := vars.Get("tenant")
+		order, _ := vars.Get("order")
+		item, _ := vars.Get("item")
+		_, _ = w.Write([]byte(fmt.Sprintf("%s %s %s %d", tenant, order, item, vars.Len())))
+	}))
+	orders := NewServeMux()
+	require.NoError(t, orders.Mount(MustCreateTemplate(`/orders/{order}`), items))
+	mux := NewServeMux()
+	require.NoError(t, mux.Mount(MustCreateTemplate(`/tenants/{tenant}`), orders))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/tenants/acme/orders/123/items/456`, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "acme 123 456 3", w.Body.String())
+}
@@ -0,0 +1,152 @@
+package urit
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SlashPolicy is the policy for how trailing slashes and empty path segments (e.g. `/foo//bar`) are treated when
+// matching paths against a template - and when generating paths from a template (using PathFrom etc.). By default,
+// a trailing slash on a path is ignored and paths with empty inner segments do not match
+//
+// A SlashPolicy is a SlashPolicyOption - policies can be combined (e.g. StrictTrailingSlash | CollapseSlashes) and
+// used as an option with NewTemplate (so that the template always uses the policy) or with any of the match or
+// PathFrom methods. Slash policies only apply to templates created with NewTemplate (not RFC 6570 templates)
+type SlashPolicy uint
+
+const (
+	// StrictTrailingSlash requires a path to have a trailing slash only if the template has one (e.g. template `/foo/`
+	// matches `/foo/` but not `/foo`) - and generated paths have a trailing slash if the template has one
+	StrictTrailingSlash SlashPolicy = 1 << iota
+	// SuggestRedirect is the same as StrictTrailingSlash - but where a path would match in its canonical form (i.e. with
+	// the trailing slash corrected and duplicate slashes collapsed), Template.MatchDetailed reports MismatchRedirect with
+	// the canonical path in MatchResult.Redirect
+	SuggestRedirect
+	// CollapseSlashes treats duplicate slashes in a path as a single slash (e.g. `/foo//bar` matches `/foo/bar`) - and
+	// duplicate slashes in generated paths (e.g. from empty var values) are collapsed
+	CollapseSlashes
+	// AllowEmptySegments allows paths with empty inner segments to be matched (e.g. `/foo//bar` matches `/foo/{id}/bar`
+	// with an empty id) - empty segments can only be matched by vars
+	AllowEmptySegments
+)
+
+// SlashPolicyOption is the option interface for controlling how trailing slashes and empty path segments are treated
+// (see SlashPolicy)
+type SlashPolicyOption interface {
+	GetSlashPolicy() SlashPolicy
+}
+
+// GetSlashPolicy returns the slash policy (so that a SlashPolicy can be used as a SlashPolicyOption)
+func (p SlashPolicy) GetSlashPolicy() SlashPolicy {
+	return p
+}
+
+const strictTrailingSlashPolicies = StrictTrailingSlash | SuggestRedirect
+const emptySegmentPolicies = CollapseSlashes | AllowEmptySegments
+
+// slashPolicy combines the slash policy with any SlashPolicyOption in the options
+func slashPolicy(policy SlashPolicy, options []interface{}) SlashPolicy {
+	for _, o := range options {
+		if sp, ok := o.(SlashPolicyOption); ok {
+			policy |= sp.GetSlashPolicy()
+		}
+	}
+	return policy
+}
+
+// slashPolicyFor returns the template slash policy combined with any SlashPolicyOption in the options
+func (t *template) slashPolicyFor(options []interface{}) SlashPolicy {
+	if len(options) == 0 {
+		return t.slashPolicy
+	}
+	return slashPolicy(t.slashPolicy, options)
+}
+
+// splitPathPolicy splits an (escaped) path into segments - the same as splitPath, except that empty inner segments
+// are dropped (for CollapseSlashes) or kept (for AllowEmptySegments)
+func splitPathPolicy(s string, policy SlashPolicy) ([]string, bool) {
+	if policy&emptySegmentPolicies == 0 {
+		return splitPath(s)
+	} else if policy&CollapseSlashes != 0 {
+		pts := make([]string, 0, strings.Count(s, "/")+1)
+		for _, pt := range strings.Split(s, "/") {
+			if pt != "" {
+				pts = append(pts, pt)
+			}
+		}
+		return pts, true
+	}
+	if s == "" || s == "/" {
+		return []string{}, true
+	}
+	return strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "/"), "/"), "/"), true
+}
+
+// hasTrailingSlash determines whether the (escaped) path has a trailing slash (the root path `/` does not)
+func hasTrailingSlash(s string) bool {
+	return strings.HasSuffix(s, "/") && strings.Trim(s, "/") != ""
+}
+
+// trailingSlashMatches checks the trailing slash of the (escaped) path against the template (only if the policy
+// is StrictTrailingSlash or SuggestRedirect)
+func (t *template) trailingSlashMatches(s string, policy SlashPolicy) bool {
+	return policy&strictTrailingSlashPolicies == 0 || hasTrailingSlash(s) == t.trailingSlash
+}
+
+// canonicalPath returns the canonical form of the (escaped) path - with duplicate slashes collapsed (unless
+// the policy allows empty segments) and the trailing slash corrected to match the template
+func (t *template) canonicalPath(s string, policy SlashPolicy) string {
+	if policy&AllowEmptySegments == 0 {
+		s = collapseSlashes(s)
+	}
+	if strings.Trim(s, "/") == "" {
+		return "/"
+	} else if t.trailingSlash && !strings.HasSuffix(s, "/") {
+		return s + "/"
+	} else if !t.trailingSlash {
+		return strings.TrimRight(s, "/")
+	}
+	return s
+}
+
+// redirectFor returns the URL (as a string) with the path replaced by the canonical path - if the URL matches the
+// template with the canonical path
+func (t *template) redirectFor(u *url.URL, canonical string, options []interface{}) (string, bool) {
+	ru := *u
+	pth, err := url.PathUnescape(canonical)
+	if err != nil {
+		return "", false
+	}
+	ru.Path, ru.RawPath = pth, canonical
+	if _, ok := t.matches(&ru, options...); ok {
+		return ru.String(), true
+	}
+	return "", false
+}
+
+// slashedPath applies the slash policy to a generated path - collapsing duplicate slashes (for CollapseSlashes) and
+// adding the template trailing slash (for StrictTrailingSlash or SuggestRedirect)
+func (t *template) slashedPath(s string, policy SlashPolicy) string {
+	if policy&CollapseSlashes != 0 {
+		s = collapseSlashes(s)
+	}
+	if policy&strictTrailingSlashPolicies != 0 && t.trailingSlash && !strings.HasSuffix(s, "/") {
+		s += "/"
+	}
+	return s
+}
+
+// collapseSlashes replaces any runs of slashes with a single slash
+func collapseSlashes(s string) string {
+	if !strings.Contains(s, "//") {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '/' || i == 0 || s[i-1] != '/' {
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,214 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitPathPolicy(t *testing.T) {
+	testCases := []struct {
+		path     string
+		policy   SlashPolicy
+		expectOk bool
+		expect   []string
+	}{
+		{`/a/b/`, 0, true, []string{"a", "b"}},
+		{`/a//b`, 0, false, nil},
+		{`/a//b`, StrictTrailingSlash, false, nil},
+		{`/a//b`, CollapseSlashes, true, []string{"a", "b"}},
+		{`//a///b//`, CollapseSlashes, true, []string{"a", "b"}},
+		{`//`, CollapseSlashes, true, []string{}},
+		{`/a//b`, AllowEmptySegments, true, []string{"a", "", "b"}},
+		{`/a/b/`, AllowEmptySegments, true, []string{"a", "b"}},
+		{`/a/b//`, AllowEmptySegments, true, []string{"a", "b", ""}},
+		{`/`, AllowEmptySegments, true, []string{}},
+		{`/a//b`, CollapseSlashes | AllowEmptySegments, true, []string{"a", "b"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			pts, ok := splitPathPolicy(tc.path, tc.policy)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				require.Equal(t, tc.expect, pts)
+			}
+		})
+	}
+}
+
+func TestTemplate_Matches_SlashPolicy(t *testing.T) {
+	testCases := []struct {
+		template string
+		path     string
+		options  []interface{}
+		expectOk bool
+	}{
+		{`/foo/{id}`, `/foo/1/`, nil, true},
+		{`/foo/{id}`, `/foo/1/`, []interface{}{StrictTrailingSlash}, false},
+		{`/foo/{id}`, `/foo/1`, []interface{}{StrictTrailingSlash}, true},
+		{`/foo/{id}/`, `/foo/1`, nil, true},
+		{`/foo/{id}/`, `/foo/1`, []interface{}{StrictTrailingSlash}, false},
+		{`/foo/{id}/`, `/foo/1/`, []interface{}{StrictTrailingSlash}, true},
+		{`/foo/{id}/`, `/foo/1/`, []interface{}{SuggestRedirect}, true},
+		{`/foo/{id}/`, `/foo/1`, []interface{}{SuggestRedirect}, false},
+		{`/`, `/`, []interface{}{StrictTrailingSlash}, true},
+		{`/foo/{id}`, `/foo//1`, nil, false},
+		{`/foo/{id}`, `/foo//1`, []interface{}{CollapseSlashes}, true},
+		{`/foo/{id}`, `/foo//1//`, []interface{}{CollapseSlashes}, true},
+		{`/foo/{id}`, `/foo//1//`, []interface{}{CollapseSlashes | StrictTrailingSlash}, false},
+		{`/foo/{id}/bar`, `/foo//bar`, nil, false},
+		{`/foo/{id}/bar`, `/foo//bar`, []interface{}{AllowEmptySegments}, true},
+		{`/foo/{id:[0-9]+}/bar`, `/foo//bar`, []interface{}{AllowEmptySegments}, false},
+		{`/foo/bar`, `/foo//bar`, []interface{}{AllowEmptySegments}, false},
+		{`/foo/{id}`, `/foo/1/`, []interface{}{StrictTrailingSlash, CollapseSlashes}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			_, ok := tmp.Matches(tc.path, tc.options...)
+			require.Equal(t, tc.expectOk, ok)
+			// same policy used with NewTemplate...
+			tmp = MustCreateTemplate(tc.template, tc.options...)
+			_, ok = tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			require.Equal(t, tc.expectOk, tmp.MatchDetailed(tc.path).Matched)
+		})
+	}
+}
+
+func TestTemplate_Matches_AllowEmptySegments(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id}/bar`, AllowEmptySegments)
+	vars, ok := tmp.Matches(`/foo//bar`)
+	require.True(t, ok)
+	id, ok := vars.Get("id")
+	require.True(t, ok)
+	require.Equal(t, "", id)
+
+	vars, remainder, ok := tmp.MatchesPrefix(`/foo//bar//baz`)
+	require.True(t, ok)
+	require.Equal(t, `//baz`, remainder)
+	require.Equal(t, 1, vars.Len())
+}
+
+func TestTemplate_MatchDetailed_SlashPolicy(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id}/`, SuggestRedirect)
+	r := tmp.MatchDetailed(`/foo/1?q=x`)
+	require.False(t, r.Matched)
+	require.Equal(t, MismatchRedirect, r.Reason)
+	require.Equal(t, `/foo/1/?q=x`, r.Redirect)
+	require.Equal(t, `path '/foo/1' should be redirected to '/foo/1/?q=x'`, r.String())
+
+	r = tmp.MatchDetailed(`https://example.com//foo//a%20b`)
+	require.Equal(t, MismatchRedirect, r.Reason)
+	require.Equal(t, `https://example.com/foo/a%20b/`, r.Redirect)
+
+	r = tmp.MatchDetailed(`/bar/1`)
+	require.Equal(t, MismatchTrailingSlash, r.Reason)
+	require.Equal(t, ``, r.Redirect)
+	require.Equal(t, `path '/bar/1' trailing slash does not match template '/foo/{id}/'`, r.String())
+
+	r = tmp.MatchDetailed(`/bar/1/`)
+	require.Equal(t, MismatchFixed, r.Reason)
+
+	r = tmp.MatchDetailed(`/foo/1/`)
+	require.True(t, r.Matched)
+
+	tmp = MustCreateTemplate(`/foo/{id}`)
+	r = tmp.MatchDetailed(`/foo/1/`, StrictTrailingSlash)
+	require.Equal(t, MismatchTrailingSlash, r.Reason)
+	r = tmp.MatchDetailed(`/foo/1/`, SuggestRedirect)
+	require.Equal(t, MismatchRedirect, r.Reason)
+	require.Equal(t, `/foo/1`, r.Redirect)
+	r = tmp.MatchDetailed(`/foo//1`, SuggestRedirect)
+	require.Equal(t, MismatchRedirect, r.Reason)
+	require.Equal(t, `/foo/1`, r.Redirect)
+	r = tmp.MatchDetailed(`/foo//1`, SuggestRedirect, CollapseSlashes)
+	require.True(t, r.Matched)
+	r = tmp.MatchDetailed(`/foo//1/`, SuggestRedirect, CollapseSlashes)
+	require.Equal(t, MismatchRedirect, r.Reason)
+	require.Equal(t, `/foo/1`, r.Redirect)
+}
+
+func TestTemplate_PathFrom_SlashPolicy(t *testing.T) {
+	testCases := []struct {
+		template string
+		vars     PathVars
+		options  []interface{}
+		expect   string
+	}{
+		{`/foo/{id}/`, Named("id", 1), nil, `/foo/1`},
+		{`/foo/{id}/`, Named("id", 1), []interface{}{StrictTrailingSlash}, `/foo/1/`},
+		{`/foo/{id}/`, Named("id", 1), []interface{}{SuggestRedirect}, `/foo/1/`},
+		{`/foo/{id}/{?q}`, Named("id", 1, "q", "x"), []interface{}{StrictTrailingSlash}, `/foo/1/?q=x`},
+		{`https://example.com/foo/`, nil, []interface{}{StrictTrailingSlash}, `https://example.com/foo/`},
+		{`/foo/{id}`, Named("id", 1), []interface{}{StrictTrailingSlash}, `/foo/1`},
+		{`/foo/{id}/bar`, Named("id", ""), nil, `/foo//bar`},
+		{`/foo/{id}/bar`, Named("id", ""), []interface{}{AllowEmptySegments}, `/foo//bar`},
+		{`/foo/{id}/bar`, Named("id", ""), []interface{}{CollapseSlashes}, `/foo/bar`},
+		{`https://example.com/{a}/{b}/`, Named("a", "", "b", ""), []interface{}{CollapseSlashes | StrictTrailingSlash}, `https://example.com/`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			pth, err := tmp.PathFrom(tc.vars, tc.options...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pth)
+			tmp = MustCreateTemplate(tc.template, tc.options...)
+			pth, err = tmp.PathFrom(tc.vars)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pth)
+		})
+	}
+}
+
+func TestTemplate_SlashPolicy_Derived(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{id}/`, StrictTrailingSlash|CollapseSlashes)
+	require.Equal(t, []interface{}{StrictTrailingSlash | CollapseSlashes}, tmp.Options())
+
+	rt, err := tmp.ResolveTo(Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/1/`, rt.OriginalTemplate())
+	_, ok := rt.Matches(`/foo//1/`)
+	require.True(t, ok)
+	_, ok = rt.Matches(`/foo/1`)
+	require.False(t, ok)
+
+	st, err := tmp.Sub(`/bar`)
+	require.NoError(t, err)
+	_, ok = st.Matches(`/foo/1//bar`)
+	require.True(t, ok)
+	_, ok = st.Matches(`/foo/1/bar/`)
+	require.False(t, ok)
+	st, err = tmp.Sub(`/bar/`)
+	require.NoError(t, err)
+	_, ok = st.Matches(`/foo/1/bar/`)
+	require.True(t, ok)
+
+	pt, err := tmp.Prefix(`/api`)
+	require.NoError(t, err)
+	pth, err := pt.PathFrom(Named("id", 1))
+	require.NoError(t, err)
+	require.Equal(t, `/api/foo/1/`, pth)
+}
+
+func TestRouter_SlashPolicy(t *testing.T) {
+	r := NewRouter()
+	require.NoError(t, r.Add(MustCreateTemplate(`/foo/{id}`, StrictTrailingSlash), "no-slash"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/foo/{id}/`, StrictTrailingSlash), "slash"))
+	require.NoError(t, r.Add(MustCreateTemplate(`/bar/{id}/baz`, AllowEmptySegments), "empty"))
+	require.Error(t, r.Add(MustCreateTemplate(`/foo/{name}/`, StrictTrailingSlash), "shadowed"))
+
+	m, ok := r.Match(`/foo/1`)
+	require.True(t, ok)
+	require.Equal(t, "no-slash", m.Payload)
+	m, ok = r.Match(`/foo/1/`)
+	require.True(t, ok)
+	require.Equal(t, "slash", m.Payload)
+	_, ok = r.Match(`/foo//1`)
+	require.False(t, ok)
+	m, ok = r.Match(`/foo//1`, CollapseSlashes)
+	require.True(t, ok)
+	require.Equal(t, "no-slash", m.Payload)
+	m, ok = r.Match(`/bar//baz`)
+	require.True(t, ok)
+	require.Equal(t, "empty", m.Payload)
+}
@@ -0,0 +1,396 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// ErrNotComparable is the error (wrapped) returned by Compare and Overlaps when either template is not a urit syntax template
+// (i.e. not created by NewTemplate - e.g. an RFC 6570 template)
+var ErrNotComparable = errors.New("template is not comparable")
+
+// Compare compares the specificity of two templates - returning a negative number if template a is more specific
+// than template b, a positive number if template b is more specific and zero if they are equally specific
+//
+// Path parts are compared in order - a fixed path part is more specific than a path part with mixed fixed and vars, which is more
+// specific than a regexp constrained var, which is more specific than an unconstrained var (and a catch-all var is least specific)
+//
+// Where path parts are equally specific, the template with more required path parts (i.e. not in optional groups and not a
+// catch-all) is more specific - then a template without optional groups or a catch-all var is more specific than one with,
+// then a template with a host is more specific than one without, then the template with more required query params is more specific
+//
+// Only urit syntax templates (created by NewTemplate) can be compared - if either template is not, an error wrapping
+// ErrNotComparable is returned
+//
+// Can be used to sort templates (e.g. sort.Slice(ts, func(i, j int) bool { c, _ := urit.Compare(ts[i], ts[j]); return c < 0 }))
+func Compare(a, b Template) (int, error) {
+	ra, rb, err := comparable(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return ra.compare(rb), nil
+}
+
+// comparable returns the templates as urit syntax templates (or an error wrapping ErrNotComparable if either is not)
+func comparable(a, b Template) (*template, *template, error) {
+	for _, t := range []Template{a, b} {
+		if _, ok := t.(*template); !ok {
+			if t == nil {
+				return nil, nil, fmt.Errorf("%w: nil template", ErrNotComparable)
+			}
+			return nil, nil, fmt.Errorf("%w: '%s'", ErrNotComparable, t.OriginalTemplate())
+		}
+	}
+	return a.(*template), b.(*template), nil
+}
+
+// compare compares the specificity of the template with another (see Compare)
+func (ra *template) compare(rb *template) int {
+	for i := 0; i < len(ra.pathParts) && i < len(rb.pathParts); i++ {
+		if d := ra.pathParts[i].specificity() - rb.pathParts[i].specificity(); d != 0 {
+			return d
+		}
+	}
+	if d := rb.requiredPartsCount() - ra.requiredPartsCount(); d != 0 {
+		return d
+	} else if oa, ob := ra.hasOptionalGroups() || ra.hasCatchAll(), rb.hasOptionalGroups() || rb.hasCatchAll(); oa != ob {
+		if oa {
+			return 1
+		}
+		return -1
+	} else if d = len(rb.pathParts) - len(ra.pathParts); d != 0 {
+		return d
+	} else if (ra.host == nil) != (rb.host == nil) {
+		if ra.host != nil {
+			return -1
+		}
+		return 1
+	}
+	return rb.requiredQueryParamsCount() - ra.requiredQueryParamsCount()
+}
+
+// requiredQueryParamsCount returns the number of required query params in the template query section
+func (t *template) requiredQueryParamsCount() int {
+	result := 0
+	for _, qp := range t.queryParts {
+		if qp.required {
+			result++
+		}
+	}
+	return result
+}
+
+// requiredPartsCount returns the number of path parts that must be matched (i.e. not in an optional group and not a catch-all)
+func (t *template) requiredPartsCount() int {
+	result := 0
+	for _, pt := range t.pathParts {
+		if pt.group == 0 && !pt.catchAll {
+			result++
+		}
+	}
+	return result
+}
+
+// Overlaps determines whether there is a path that matches both templates - and if so, returns an example of such a path
+//
+// The analysis is heuristic where both templates have regexp constrained vars for the same path part (example values are
+// generated from each regexp and checked against the other) - so an overlap may not be found where the regexps only
+// share obscure values
+//
+// Only urit syntax templates (created by NewTemplate) can be analysed - if either template is not, an error wrapping
+// ErrNotComparable is returned
+func Overlaps(a, b Template) (string, bool, error) {
+	ra, rb, err := comparable(a, b)
+	if err != nil {
+		return "", false, err
+	}
+	example, ok := ra.overlaps(rb)
+	return example, ok, nil
+}
+
+// overlaps determines whether there is a path that matches both templates (see Overlaps)
+func (ra *template) overlaps(rb *template) (string, bool) {
+	var hb strings.Builder
+	if !overlapHost(&hb, ra.host, rb.host) {
+		return "", false
+	}
+	maxN := len(ra.pathParts)
+	if len(rb.pathParts) > maxN {
+		maxN = len(rb.pathParts)
+	}
+	for n := 0; n <= maxN+1; n++ {
+		if !ra.allowsSegmentCount(n) || !rb.allowsSegmentCount(n) {
+			continue
+		}
+		var pb strings.Builder
+		pb.WriteString(hb.String())
+		ok := true
+		for i := 0; i < n && ok; i++ {
+			var s string
+			if s, ok = commonValue(ra.partAt(i), rb.partAt(i)); ok {
+				pb.WriteString("/" + _PercentEncoded.Encode(s))
+			}
+		}
+		if !ok {
+			continue
+		}
+		if n == 0 {
+			pb.WriteString("/")
+		}
+		if q, ok := overlapQuery(ra.queryParts, rb.queryParts); ok {
+			pb.WriteString(q)
+			example := pb.String()
+			// make sure the example path really does match both...
+			if _, ok := ra.Matches(example); ok {
+				if _, ok = rb.Matches(example); ok {
+					return example, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// partAt returns the path part used to match the path segment at the index (taking into account any catch-all)
+func (t *template) partAt(i int) *pathPart {
+	if t.hasCatchAll() && i >= len(t.pathParts)-1 {
+		return &t.pathParts[len(t.pathParts)-1]
+	}
+	return &t.pathParts[i]
+}
+
+// overlapHost writes an example scheme and host that matches both host templates (either may be nil - meaning any host)
+func overlapHost(sb *strings.Builder, ha *hostTemplate, hb *hostTemplate) bool {
+	if ha == nil && hb == nil {
+		return true
+	} else if ha == nil {
+		ha, hb = hb, nil
+	}
+	if hb != nil && len(ha.labels) != len(hb.labels) {
+		return false
+	}
+	s, ok := commonValue(&ha.scheme, hb.schemePart())
+	if !ok {
+		return false
+	}
+	sb.WriteString(s + "://")
+	for i := range ha.labels {
+		var ol *pathPart
+		if hb != nil {
+			ol = &hb.labels[i]
+		}
+		if s, ok = commonValue(&ha.labels[i], ol); !ok {
+			return false
+		} else if i > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(s)
+	}
+	pa, pb := ha.port, hb.portPart()
+	if pa == nil {
+		pa, pb = pb, nil
+	}
+	if pa != nil {
+		if s, ok = commonValue(pa, pb, portPathPart); !ok {
+			return false
+		}
+		sb.WriteString(":" + s)
+	}
+	return true
+}
+
+// portPathPart constrains example ports to be numeric
+var portPathPart = &pathPart{regexp: regexp.MustCompile(`^[0-9]+$`)}
+
+func (h *hostTemplate) schemePart() *pathPart {
+	if h == nil {
+		return nil
+	}
+	return &h.scheme
+}
+
+func (h *hostTemplate) portPart() *pathPart {
+	if h == nil {
+		return nil
+	}
+	return h.port
+}
+
+func overlapQuery(qa []queryPart, qb []queryPart) (string, bool) {
+	var sb strings.Builder
+	done := map[string]bool{}
+	for _, qp := range append(append(make([]queryPart, 0, len(qa)+len(qb)), qa...), qb...) {
+		if !qp.required || done[qp.name] {
+			continue
+		}
+		done[qp.name] = true
+		rxs := make([]*regexp.Regexp, 0, 2)
+		for _, oqp := range append(append(make([]queryPart, 0, len(qa)+len(qb)), qa...), qb...) {
+			if oqp.name == qp.name {
+				rxs = append(rxs, oqp.regexp)
+			}
+		}
+		s, ok := commonRegexpValue(rxs...)
+		if !ok {
+			return "", false
+		}
+		sb.WriteString(ampersandOrQuestionMark(sb.Len() == 0) + url.QueryEscape(qp.name) + "=" + url.QueryEscape(s))
+	}
+	return sb.String(), true
+}
+
+// commonValue finds a value that matches all the path parts (any nil path parts are ignored - meaning any value)
+func commonValue(pts ...*pathPart) (string, bool) {
+	parts := make([]*pathPart, 0, len(pts))
+	for _, pt := range pts {
+		if pt != nil {
+			parts = append(parts, pt)
+		}
+	}
+	rxs := make([]*regexp.Regexp, 0, len(parts))
+	for _, pt := range parts {
+		if pt.fixed {
+			rxs = append(rxs, regexp.MustCompile("^"+regexp.QuoteMeta(pt.decodedFixedValue())+"$"))
+		} else if len(pt.subParts) > 0 {
+			rxs = append(rxs, pt.overallRegexp())
+		} else {
+			rxs = append(rxs, pt.regexp)
+		}
+	}
+	for _, c := range regexpExamples(rxs...) {
+		if c != "" && !strings.Contains(c, "/") && matchesAll(c, rxs) && convertsAll(c, parts) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func commonRegexpValue(rxs ...*regexp.Regexp) (string, bool) {
+	for _, c := range regexpExamples(rxs...) {
+		if matchesAll(c, rxs) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func matchesAll(s string, rxs []*regexp.Regexp) bool {
+	for _, rx := range rxs {
+		if rx != nil && !rx.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func convertsAll(s string, parts []*pathPart) bool {
+	for _, pt := range parts {
+		if _, ok := pt.convertTyped(s); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultExamples = []string{"x", "1", "a", "0"}
+
+// regexpExamples generates example values for the regexps (plus some default examples)
+func regexpExamples(rxs ...*regexp.Regexp) []string {
+	result := make([]string, 0)
+	for _, rx := range rxs {
+		if rx != nil {
+			if re, err := syntax.Parse(rx.String(), syntax.Perl); err == nil {
+				result = append(result, syntaxExamples(re.Simplify())...)
+			}
+		}
+	}
+	return append(result, defaultExamples...)
+}
+
+const maxExamples = 16
+
+func syntaxExamples(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCharClass:
+		return charClassExamples(re.Rune)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return []string{"x"}
+	case syntax.OpCapture:
+		return syntaxExamples(re.Sub[0])
+	case syntax.OpStar, syntax.OpQuest:
+		return append([]string{""}, syntaxExamples(re.Sub[0])...)
+	case syntax.OpPlus:
+		return syntaxExamples(re.Sub[0])
+	case syntax.OpRepeat:
+		result := []string{""}
+		for i := 0; i < re.Min; i++ {
+			result = concatExamples(result, syntaxExamples(re.Sub[0]))
+		}
+		return result
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, sub := range re.Sub {
+			result = concatExamples(result, syntaxExamples(sub))
+		}
+		return result
+	case syntax.OpAlternate:
+		result := make([]string, 0)
+		for _, sub := range re.Sub {
+			result = append(result, syntaxExamples(sub)...)
+		}
+		if len(result) > maxExamples {
+			result = result[:maxExamples]
+		}
+		return result
+	}
+	return []string{""}
+}
+
+func concatExamples(heads []string, tails []string) []string {
+	result := make([]string, 0, len(heads))
+	for _, h := range heads {
+		for _, t := range tails {
+			if len(result) < maxExamples {
+				result = append(result, h+t)
+			}
+		}
+	}
+	return result
+}
+
+// charClassExamples returns example runes (as strings) from a char class (preferring '1', 'a' and 'x' where in the class)
+func charClassExamples(ranges []rune) []string {
+	result := make([]string, 0, 2)
+	in := func(r rune) bool {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if r >= ranges[i] && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range []rune{'1', 'a', 'x', 'A'} {
+		if in(r) {
+			result = append(result, string(r))
+		}
+	}
+	for i := 0; i+1 < len(ranges) && len(result) < 3; i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r-ranges[i] < 128; r++ {
+			if unicode.IsPrint(r) && r != '/' && r != ' ' {
+				result = append(result, string(r))
+				break
+			}
+		}
+	}
+	if len(result) > 2 {
+		result = result[:2]
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		a      string
+		b      string
+		expect int
+	}{
+		{`/users/me`, `/users/{id}`, -1},
+		{`/users/{id}`, `/users/me`, 1},
+		{`/users/{id:[0-9]+}`, `/users/{id}`, -1},
+		{`/users/{id}-{x}`, `/users/{id:[0-9]+}`, -1},
+		{`/users/{id}`, `/users/{path...}`, -1},
+		{`/users/{path...:[a-z]+}`, `/users/{path...}`, -1},
+		{`/users/{id}`, `/users/{other}`, 0},
+		{`/users/{id}/orders`, `/users/{id}`, -1},
+		{`https://example.com/users/{id}`, `/users/{id}`, -1},
+		{`/users/{id}`, `https://example.com/users/{id}`, 1},
+		{`/users{?q!}`, `/users{?q}`, -1},
+		{`/users{?q}`, `/users{?q!}`, 1},
+		{`/a/{p...}`, `/a`, 1},
+		{`/a`, `/a/{p...}`, -1},
+		{`/a/{id}[/{v}]`, `/a/{id}`, 1},
+		{`/a/{id}`, `/a/{id}[/{v}]`, -1},
+		{`/a/{id}[/{v}]`, `/a/{id}/{x}`, 1},
+		{`/a/{id}[/{v}[/{w}]]`, `/a/{id}[/{v}]`, -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			c, err := Compare(MustCreateTemplate(tc.a), MustCreateTemplate(tc.b))
+			require.NoError(t, err)
+			if tc.expect < 0 {
+				require.Less(t, c, 0)
+			} else if tc.expect > 0 {
+				require.Greater(t, c, 0)
+			} else {
+				require.Equal(t, 0, c)
+			}
+		})
+	}
+	_, err := Compare(MustCreateRfc6570Template(`/users{/id}`), MustCreateTemplate(`/users`))
+	require.ErrorIs(t, err, ErrNotComparable)
+	require.Equal(t, "template is not comparable: '/users{/id}'", err.Error())
+	_, err = Compare(MustCreateTemplate(`/users`), nil)
+	require.ErrorIs(t, err, ErrNotComparable)
+
+	ts := []Template{
+		MustCreateTemplate(`/static/{path...}`),
+		MustCreateTemplate(`/users/{id}`),
+		MustCreateTemplate(`/users/{id:[0-9]+}`),
+		MustCreateTemplate(`/users/me`),
+	}
+	sort.SliceStable(ts, func(i, j int) bool {
+		c, _ := Compare(ts[i], ts[j])
+		return c < 0
+	})
+	require.Equal(t, `/users/me`, ts[0].OriginalTemplate())
+	require.Equal(t, `/users/{id:[0-9]+}`, ts[1].OriginalTemplate())
+	require.Equal(t, `/users/{id}`, ts[2].OriginalTemplate())
+	require.Equal(t, `/static/{path...}`, ts[3].OriginalTemplate())
+}
+
+func TestOverlaps(t *testing.T) {
+	testCases := []struct {
+		a             string
+		b             string
+		expectOk      bool
+		expectExample string
+	}{
+		{`/users/me`, `/users/{id}`, true, `/users/me`},
+		{`/users/me`, `/users/{id:[0-9]+}`, false, ``},
+		{`/users/{id:[0-9]+}`, `/users/{name:[a-z]+}`, false, ``},
+		{`/users/{id:[0-9]+}`, `/users/{name:[a-z0-9]+}`, true, `/users/1`},
+		{`/users/{id}`, `/orders/{id}`, false, ``},
+		{`/users/{id}`, `/users/{id}/orders`, false, ``},
+		{`/users/{id}[/orders]`, `/users/{id}/orders`, true, `/users/x/orders`},
+		{`/users/{id}`, `/users/{path...}`, true, `/users/x`},
+		{`/static/{path...}`, `/static/css/{file}`, true, `/static/css/x`},
+		{`/static/{path...:[a-z]+}`, `/static/css/{file:[0-9]+}`, false, ``},
+		{`/users/{id}-{x}`, `/users/{id:[0-9]+}`, false, ``},
+		{`/users/{id}-{x}`, `/users/{id:[0-9]+-[a-z]+}`, true, `/users/1-a`},
+		{`/credits/{d:date}`, `/credits/{d:[0-9-]+}`, true, `/credits/1111-11-11`},
+		{`/orders/{s:enum(open|closed)}`, `/orders/closed`, true, `/orders/closed`},
+		{`/users/{id}`, `/users/a%20b`, true, `/users/a%20b`},
+		{`/search{?q!}`, `/search{?page!:[0-9]+}`, true, `/search?q=x&page=1`},
+		{`/search{?q!:[a-z]+}`, `/search{?q!:[0-9]+}`, false, ``},
+		{`https://{tenant}.example.com/users`, `/users`, true, `https://x.example.com/users`},
+		{`https://{tenant}.example.com/users`, `https://www.{domain}.com/users`, true, `https://www.example.com/users`},
+		{`https://{tenant}.example.com/users`, `https://example.com/users`, false, ``},
+		{`http://localhost:{port}/users`, `http://localhost/users`, true, `http://localhost:1/users`},
+		{`[/users]`, `/`, true, `/`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			example, ok, err := Overlaps(MustCreateTemplate(tc.a), MustCreateTemplate(tc.b))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectOk, ok)
+			require.Equal(t, tc.expectExample, example)
+			_, ok, err = Overlaps(MustCreateTemplate(tc.b), MustCreateTemplate(tc.a))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectOk, ok)
+		})
+	}
+	_, ok, err := Overlaps(MustCreateTemplate(`/users`), MustCreateRfc6570Template(`/users{/id}`))
+	require.False(t, ok)
+	require.ErrorIs(t, err, ErrNotComparable)
+}
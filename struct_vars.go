@@ -0,0 +1,192 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structVars extracts the path vars (from `urit` tagged fields) and query params (from `query` tagged fields) of a struct
+//
+// for Positions vars type, the `urit` tags must be the var positions (e.g. `urit:"0"`)
+func structVars(v interface{}, varsType PathVarsType) (PathVars, QueryParams, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, errors.New("value must be a struct or pointer to a struct")
+	}
+	sc := &structCollector{
+		varsType:   varsType,
+		vars:       newPathVars(varsType),
+		params:     &queryParams{params: map[string][]interface{}{}, sorted: true},
+		positional: map[int]interface{}{},
+	}
+	if err := sc.collect(rv); err != nil {
+		return nil, nil, err
+	}
+	if varsType == Positions {
+		posns := make([]int, 0, len(sc.positional))
+		for pos := range sc.positional {
+			posns = append(posns, pos)
+		}
+		sort.Ints(posns)
+		for i, pos := range posns {
+			if pos != i {
+				break
+			}
+			_ = sc.vars.AddPositionalValue(sc.positional[pos])
+		}
+	}
+	return sc.vars, sc.params, nil
+}
+
+type structCollector struct {
+	varsType   PathVarsType
+	vars       PathVars
+	params     QueryParams
+	positional map[int]interface{}
+}
+
+func (sc *structCollector) collect(sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		varTag, isVar := f.Tag.Lookup("urit")
+		queryTag, isQuery := f.Tag.Lookup("query")
+		if !isVar && !isQuery {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if err := sc.collect(sv.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		} else if f.PkgPath != "" {
+			continue
+		}
+		if isVar && varTag != "-" {
+			name, layout := parseBindTag(varTag, f.Name)
+			values, err := structFieldValues(sv.Field(i), layout)
+			if err != nil {
+				return fmt.Errorf("field '%s': %s", f.Name, err.Error())
+			}
+			if sc.varsType == Positions {
+				pos, err := strconv.Atoi(name)
+				if err != nil {
+					return fmt.Errorf("field '%s': tag must be a position for positional path vars", f.Name)
+				}
+				if len(values) > 0 {
+					sc.positional[pos] = values[0]
+				}
+			} else {
+				for _, value := range values {
+					_ = sc.vars.AddNamedValue(name, value)
+				}
+			}
+		}
+		if isQuery && queryTag != "-" {
+			name, layout := parseBindTag(queryTag, f.Name)
+			fv := sv.Field(i)
+			if strings.Contains(queryTag, ",omitempty") && fv.IsZero() {
+				continue
+			}
+			values, err := structFieldValues(fv, layout)
+			if err != nil {
+				return fmt.Errorf("field '%s': %s", f.Name, err.Error())
+			}
+			for _, value := range values {
+				sc.params.Add(name, value)
+			}
+		}
+	}
+	return nil
+}
+
+// structFieldValues returns the string value(s) of a struct field (slices give multiple values, nil pointers give none)
+func structFieldValues(fv reflect.Value, layout string) ([]string, error) {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		result := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			vs, err := structFieldValues(fv.Index(i), layout)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, vs...)
+		}
+		return result, nil
+	}
+	if tv, ok := fv.Interface().(time.Time); ok && layout != "" {
+		return []string{tv.Format(layout)}, nil
+	} else if fv.Kind() == reflect.Slice {
+		return []string{string(fv.Bytes())}, nil
+	}
+	str, err := getValue(fv.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return []string{str}, nil
+}
+
+// structPathOptions merges the query params (from a struct) into the path options
+//
+// any query params explicitly passed as an option take precedence over those from the struct
+func structPathOptions(params QueryParams, options []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(options)+1)
+	merged := false
+	for _, o := range options {
+		if q, ok := o.(QueryParamsOption); ok && !merged {
+			merged = true
+			if qp, ok := q.(QueryParams); ok {
+				qp = qp.Clone()
+				for k, vs := range params.(*queryParams).params {
+					if !qp.Has(k) {
+						for _, v := range vs {
+							qp.Add(k, v)
+						}
+					}
+				}
+				o = qp
+			}
+		}
+		result = append(result, o)
+	}
+	if !merged && len(params.(*queryParams).params) > 0 {
+		result = append(result, params)
+	}
+	return result
+}
+
+// checkMissingVars checks that all the template vars are present in the vars - returning an error listing all the missing vars
+//
+// any names in supplied are not checked (e.g. template query params supplied by query params)
+func checkMissingVars(t Template, vars PathVars, supplied map[string]bool) error {
+	missing := make([]string, 0)
+	seen := map[string]bool{}
+	for _, tv := range t.Vars() {
+		if tv.Optional || supplied[tv.Name] {
+			continue
+		} else if vars.VarsType() == Positions {
+			if _, ok := vars.GetPositional(tv.Position); !ok {
+				missing = append(missing, "'"+strconv.Itoa(tv.Position)+"'")
+			}
+		} else if _, ok := vars.GetNamed(tv.Name, tv.NamedPosition); !ok && !seen[tv.Name] {
+			seen[tv.Name] = true
+			missing = append(missing, "'"+tv.Name+"'")
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing path vars: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package urit
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type structVarsBase struct {
+	Tenant string `urit:"tenant"`
+}
+
+type structVarsRequest struct {
+	structVarsBase
+	Year     int       `urit:"year"`
+	Month    *int      `urit:"month"`
+	Day      time.Time `urit:"day,layout=2006-01-02"`
+	Ignored  string    `urit:"-"`
+	Untagged string
+	Sort     string   `query:"sort,omitempty"`
+	Page     int      `query:"page"`
+	Fields   []string `query:"fields"`
+}
+
+func TestTemplate_PathFromStruct(t *testing.T) {
+	tmp := MustCreateTemplate(`/tenants/{tenant}/credits/{year}/{month}/{day}`)
+	month := 11
+	req := &structVarsRequest{
+		structVarsBase: structVarsBase{Tenant: "a b"},
+		Year:           2022,
+		Month:          &month,
+		Day:            time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC),
+		Page:           1,
+		Fields:         []string{"x", "y"},
+	}
+	pth, err := tmp.PathFromStruct(req)
+	require.NoError(t, err)
+	require.Equal(t, `/tenants/a%20b/credits/2022/11/2022-11-02?fields=x&fields=y&page=1`, pth)
+
+	req.Sort = "asc"
+	q, err := NewQueryParams("page", 2, "extra", true)
+	require.NoError(t, err)
+	pth, err = tmp.PathFromStruct(*req, NewHost(`https://www.example.com`), q)
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/tenants/a%20b/credits/2022/11/2022-11-02?extra=true&fields=x&fields=y&page=2&sort=asc`, pth)
+	_, ok := q.Get("sort")
+	require.False(t, ok)
+
+	_, err = tmp.PathFromStruct(&structVarsRequest{})
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: 'month'`, err.Error())
+
+	_, err = tmp.PathFromStruct(&struct{}{})
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: 'tenant', 'year', 'month', 'day'`, err.Error())
+
+	_, err = tmp.PathFromStruct("not a struct")
+	require.Error(t, err)
+	require.Equal(t, `value must be a struct or pointer to a struct`, err.Error())
+
+	_, err = tmp.PathFromStruct(&struct {
+		Bad func() `urit:"tenant"`
+	}{Bad: func() {}})
+	require.Error(t, err)
+	require.Equal(t, `field 'Bad': unknown value type`, err.Error())
+
+	_, err = tmp.PathFromStruct(&struct {
+		Bad func() `query:"q"`
+	}{Bad: func() {}})
+	require.Error(t, err)
+	require.Equal(t, `field 'Bad': unknown value type`, err.Error())
+}
+
+func TestTemplate_PathFromStruct_Repeated(t *testing.T) {
+	tmp := MustCreateTemplate(`/{id}/{id}/{id}`)
+	pth, err := tmp.PathFromStruct(struct {
+		Ids []int `urit:"id"`
+	}{Ids: []int{1, 2, 3}})
+	require.NoError(t, err)
+	require.Equal(t, `/1/2/3`, pth)
+
+	_, err = tmp.PathFromStruct(struct {
+		Ids []int `urit:"id"`
+	}{Ids: []int{1}})
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: 'id'`, err.Error())
+}
+
+func TestTemplate_PathFromStruct_Positional(t *testing.T) {
+	tmp := MustCreateTemplate(`/credits/?/?`)
+	pth, err := tmp.PathFromStruct(struct {
+		Year  int `urit:"0"`
+		Month int `urit:"1"`
+	}{Year: 2022, Month: 11})
+	require.NoError(t, err)
+	require.Equal(t, `/credits/2022/11`, pth)
+
+	_, err = tmp.PathFromStruct(struct {
+		Month int `urit:"1"`
+	}{Month: 11})
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: '0', '1'`, err.Error())
+
+	_, err = tmp.PathFromStruct(struct {
+		Month int `urit:"month"`
+	}{Month: 11})
+	require.Error(t, err)
+	require.Equal(t, `field 'Month': tag must be a position for positional path vars`, err.Error())
+}
+
+func TestTemplate_RequestFromStruct(t *testing.T) {
+	tmp := MustCreateTemplate(`/credits/{year}`)
+	req, err := tmp.RequestFromStruct(http.MethodGet, &struct {
+		Year int    `urit:"year"`
+		Sort string `query:"sort"`
+	}{Year: 2022, Sort: "desc"}, nil, NewHost(`https://www.example.com`))
+	require.NoError(t, err)
+	require.Equal(t, `https://www.example.com/credits/2022?sort=desc`, req.URL.String())
+
+	_, err = tmp.RequestFromStruct(http.MethodGet, &struct{}{}, nil)
+	require.Error(t, err)
+	require.Equal(t, `missing path vars: 'year'`, err.Error())
+}
+
+func TestRfc6570Template_PathFromStruct(t *testing.T) {
+	tmp := MustCreateRfc6570Template(`/users{/id}{?fields,limit}`)
+	pth, err := tmp.PathFromStruct(&struct {
+		Id     string   `urit:"id"`
+		Fields []string `query:"fields"`
+		Sort   string   `query:"sort,omitempty"`
+	}{Id: "123", Fields: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Equal(t, `/users/123?fields=a,b`, pth)
+
+	pth, err = tmp.PathFromStruct(&struct{}{})
+	require.NoError(t, err)
+	require.Equal(t, `/users`, pth)
+
+	req, err := tmp.RequestFromStruct(http.MethodGet, &struct {
+		Id string `urit:"id"`
+	}{Id: "123"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, `/users/123`, req.URL.String())
+
+	_, err = tmp.PathFromStruct(nil)
+	require.Error(t, err)
+	_, err = tmp.RequestFromStruct(http.MethodGet, nil, nil)
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package urit
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-andiamo/splitter"
 	"io"
 	"net/http"
@@ -21,16 +22,24 @@ const (
 // returns an error if the path cannot be parsed into a template
 //
 // The options can be any FixedMatchOption or VarMatchOption - which can be used
-// to extend or check fixed or variable path parts
+// to extend or check fixed or variable path parts - and a SubPartsOption (GreedySubParts or NonGreedySubParts)
+// to control how unconstrained vars in path segments with multiple parts (e.g. `{a}-{b}`) are matched - and a
+// SlashPolicyOption (e.g. StrictTrailingSlash) to control how trailing slashes and empty path segments are treated - and
+// a NormalizeOption (e.g. Normalized) to normalize paths before matching
+//
+// Templates are fully compiled when created (so matching does not modify the template - see Template)
 func NewTemplate(path string, options ...interface{}) (Template, error) {
 	fs, vs, so := separateParseOptions(options)
 	return (&template{
-		originalTemplate: slashPrefix(path),
-		pathParts:        make([]pathPart, 0),
-		posVarsCount:     0,
-		fixedMatchOpts:   fs,
-		varMatchOpts:     vs,
-		pathSplitOpts:    so,
+		originalTemplate:  slashPrefix(path),
+		pathParts:         make([]pathPart, 0),
+		posVarsCount:      0,
+		fixedMatchOpts:    fs,
+		varMatchOpts:      vs,
+		pathSplitOpts:     so,
+		nonGreedySubParts: nonGreedySubParts(options),
+		slashPolicy:       slashPolicy(0, options),
+		normalize:         normalizeOption(nil, options),
 	}).parse()
 }
 
@@ -44,11 +53,24 @@ func MustCreateTemplate(path string, options ...interface{}) Template {
 }
 
 // Template is the interface for a URI template
+//
+// Templates are immutable once created - so a Template can be safely shared and used concurrently (e.g. matched from
+// many HTTP handler goroutines). Methods that derive a template (Sub and ResolveTo) return a new Template and never
+// modify the original
+//
+// Use NewTemplate (or NewRfc6570Template) to create a Template (Template is not intended to be implemented outside
+// urit - and may gain new methods)
 type Template interface {
 	// PathFrom generates a path from the template given the specified path vars
+	//
+	// if a HostOption is specified, it overrides any template host (and positional vars are then only used for the path)
 	PathFrom(vars PathVars, options ...interface{}) (string, error)
 	// RequestFrom generates a http.Request from the template given the specified path vars
 	RequestFrom(method string, vars PathVars, body io.Reader, options ...interface{}) (*http.Request, error)
+	// PathFromStruct generates a path from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+	PathFromStruct(v interface{}, options ...interface{}) (string, error)
+	// RequestFromStruct generates a http.Request from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+	RequestFromStruct(method string, v interface{}, body io.Reader, options ...interface{}) (*http.Request, error)
 	// Matches checks whether the specified path matches the template -
 	// and if a successful match, returns the extracted path vars
 	Matches(path string, options ...interface{}) (PathVars, bool)
@@ -58,10 +80,28 @@ type Template interface {
 	// MatchesRequest checks whether the specified request matches the template -
 	// and if a successful match, returns the extracted path vars
 	MatchesRequest(req *http.Request, options ...interface{}) (PathVars, bool)
+	// MatchesPrefix checks whether the start of the specified path matches the template -
+	// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+	MatchesPrefix(path string, options ...interface{}) (PathVars, string, bool)
+	// MatchesPrefixUrl checks whether the start of the specified URL path matches the template -
+	// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+	MatchesPrefixUrl(u url.URL, options ...interface{}) (PathVars, string, bool)
+	// MatchesPrefixRequest checks whether the start of the specified request path matches the template -
+	// and if a successful match, returns the extracted path vars and the remainder of the path (escaped and always starting with "/")
+	MatchesPrefixRequest(req *http.Request, options ...interface{}) (PathVars, string, bool)
+	// MatchDetailed checks whether the specified path matches the template - returning a MatchResult with
+	// the extracted path vars or, if not a successful match, the reason it did not match
+	MatchDetailed(path string, options ...interface{}) MatchResult
 	// Sub generates a new template with added sub-path
 	Sub(path string, options ...interface{}) (Template, error)
+	// Prefix generates a new template with the base path prefixed (after any host)
+	Prefix(base string, options ...interface{}) (Template, error)
 	// ResolveTo generates a new template, filling in any known path vars from the supplied vars
 	ResolveTo(vars PathVars) (Template, error)
+	// Options returns the options the template was created with (any FixedMatchOption, VarMatchOption, SubPartsOption etc.)
+	//
+	// templates generated using Sub or ResolveTo inherit the options of the template
+	Options() []interface{}
 	// VarsType returns the path vars type (Positions or Names)
 	VarsType() PathVarsType
 	// Vars returns the path vars of the template
@@ -73,44 +113,96 @@ type Template interface {
 }
 
 type template struct {
-	originalTemplate string
-	pathParts        []pathPart
-	posVarsCount     int
-	nameVarsCount    int
-	varsType         PathVarsType
-	fixedMatchOpts   fixedMatchOptions
-	varMatchOpts     varMatchOptions
-	pathSplitOpts    []splitter.Option
+	originalTemplate  string
+	pathParts         []pathPart
+	posVarsCount      int
+	nameVarsCount     int
+	varsType          PathVarsType
+	fixedMatchOpts    fixedMatchOptions
+	varMatchOpts      varMatchOptions
+	pathSplitOpts     []splitter.Option
+	queryParts        []queryPart
+	host              *hostTemplate
+	nonGreedySubParts bool
+	slashPolicy       SlashPolicy
+	trailingSlash     bool
+	normalize         NormalizeOption
 }
 
 // PathFrom generates a path from the template given the specified path vars
+//
+// if a HostOption is specified, it overrides any template host (and positional vars are then only used for the path)
 func (t *template) PathFrom(vars PathVars, options ...interface{}) (string, error) {
-	hostOption, queryOption, _, varMatches := separatePathOptions(options)
-	return t.buildPath(vars, hostOption, queryOption, varMatches)
+	hostOption, queryOption, _, varMatches, encoding := separatePathOptions(options)
+	return t.buildPath(vars, hostOption, queryOption, varMatches, encoding, t.slashPolicyFor(options))
 }
 
-func (t *template) buildPath(vars PathVars, hostOption HostOption, queryOption QueryParamsOption, varMatches varMatchOptions) (string, error) {
+func (t *template) buildPath(vars PathVars, hostOption HostOption, queryOption QueryParamsOption, varMatches varMatchOptions, encoding EncodingOption, policy SlashPolicy) (string, error) {
 	var pb strings.Builder
-	if hostOption != nil {
-		pb.WriteString(hostOption.GetAddress())
-	}
 	tracker := &positionsTracker{
 		vars:           vars,
 		varPosition:    0,
 		pathPosition:   0,
 		namedPositions: map[string]int{},
 		varMatches:     varMatches,
+		encoding:       encoding,
+	}
+	if hostOption != nil {
+		// an explicit host overrides any template host (so positional vars are only used for the path)...
+		pb.WriteString(hostOption.GetAddress())
+	} else if t.host != nil {
+		if str, err := t.host.hostFrom(tracker); err == nil {
+			pb.WriteString(str)
+		} else {
+			return "", err
+		}
+	}
+	upTo := 0
+	if t.hasOptionalGroups() {
+		var err error
+		if upTo, err = t.optionalGroupsUpTo(vars, hostOption == nil); err != nil {
+			return "", err
+		}
 	}
+	var sb strings.Builder
 	for _, pt := range t.pathParts {
+		if pt.group > upTo {
+			break
+		}
 		if str, err := pt.pathFrom(tracker); err == nil {
-			pb.WriteString(str)
+			sb.WriteString(str)
 		} else {
 			return "", err
 		}
 		tracker.pathPosition++
 	}
+	pb.WriteString(t.slashedPath(sb.String(), policy))
+	var qb strings.Builder
+	if len(t.queryParts) > 0 {
+		params, _ := queryOption.(QueryParams)
+		for _, qp := range t.queryParts {
+			if err := qp.queryFrom(&qb, tracker, params); err != nil {
+				return "", err
+			}
+		}
+		if params != nil {
+			// query params used by the template query section are not added again...
+			params = params.Clone()
+			for _, qp := range t.queryParts {
+				params.Del(qp.name)
+			}
+			queryOption = params
+		}
+		if len(t.pathParts) == 0 && qb.Len() > 0 {
+			pb.WriteString("/")
+		}
+		pb.WriteString(qb.String())
+	}
 	if queryOption != nil {
 		if q, err := queryOption.GetQuery(); err == nil {
+			if q != "" && qb.Len() > 0 {
+				q = "&" + q[1:]
+			}
 			pb.WriteString(q)
 		} else {
 			return "", err
@@ -121,11 +213,49 @@ func (t *template) buildPath(vars PathVars, hostOption HostOption, queryOption Q
 
 // RequestFrom generates a http.Request from the template given the specified path vars
 func (t *template) RequestFrom(method string, vars PathVars, body io.Reader, options ...interface{}) (*http.Request, error) {
-	hostOption, queryOption, headerOption, varMatches := separatePathOptions(options)
-	url, err := t.buildPath(vars, hostOption, queryOption, varMatches)
+	hostOption, queryOption, headerOption, varMatches, encoding := separatePathOptions(options)
+	url, err := t.buildPath(vars, hostOption, queryOption, varMatches, encoding, t.slashPolicyFor(options))
 	if err != nil {
 		return nil, err
 	}
+	return newRequest(method, url, body, headerOption)
+}
+
+// PathFromStruct generates a path from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+//
+// returns an error listing all missing path vars if any template path vars are not supplied by the struct
+func (t *template) PathFromStruct(v interface{}, options ...interface{}) (string, error) {
+	vars, params, err := t.structVars(v)
+	if err != nil {
+		return "", err
+	}
+	return t.PathFrom(vars, structPathOptions(params, options)...)
+}
+
+// RequestFromStruct generates a http.Request from the template given the `urit` tagged fields (and `query` tagged fields) of a struct
+//
+// returns an error listing all missing path vars if any template path vars are not supplied by the struct
+func (t *template) RequestFromStruct(method string, v interface{}, body io.Reader, options ...interface{}) (*http.Request, error) {
+	vars, params, err := t.structVars(v)
+	if err != nil {
+		return nil, err
+	}
+	return t.RequestFrom(method, vars, body, structPathOptions(params, options)...)
+}
+
+func (t *template) structVars(v interface{}) (PathVars, QueryParams, error) {
+	vars, params, err := structVars(v, t.varsType)
+	if err == nil {
+		supplied := map[string]bool{}
+		for _, qp := range t.queryParts {
+			supplied[qp.name] = params.Has(qp.name)
+		}
+		err = checkMissingVars(t, vars, supplied)
+	}
+	return vars, params, err
+}
+
+func newRequest(method string, url string, body io.Reader, headerOption HeadersOption) (*http.Request, error) {
 	result, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
@@ -145,63 +275,164 @@ func (t *template) RequestFrom(method string, vars PathVars, body io.Reader, opt
 // Matches checks whether the specified path matches the template -
 // and if a successful match, returns the extracted path vars
 func (t *template) Matches(path string, options ...interface{}) (PathVars, bool) {
+	if t.rejects(path, options) {
+		return nil, false
+	}
 	u, err := url.Parse(path)
 	if err != nil {
 		return nil, false
 	}
-	return t.matches(u.Path, options...)
+	return t.matchesUrl(u, options)
 }
 
 // MatchesUrl checks whether the specified URL path matches the template -
 // and if successful match, returns the extracted path vars
 func (t *template) MatchesUrl(u url.URL, options ...interface{}) (PathVars, bool) {
-	return t.matches(u.Path, options...)
+	return t.matches(&u, options...)
 }
 
 // MatchesRequest checks whether the specified request matches the template -
 // and if a successful match, returns the extracted path vars
+//
+// for templates with a host, the request host is used where the request URL has no host
 func (t *template) MatchesRequest(req *http.Request, options ...interface{}) (PathVars, bool) {
-	return t.matches(req.URL.Path, options...)
+	if t.rejects(req.URL.EscapedPath(), options) {
+		return nil, false
+	}
+	return t.matchesUrl(requestUrl(req), options)
+}
+
+// matches is the shared match path for URLs - rejecting, without allocating, URL paths that definitely do not match
+func (t *template) matches(u *url.URL, options ...interface{}) (PathVars, bool) {
+	if t.rejects(u.EscapedPath(), options) {
+		return nil, false
+	}
+	return t.matchesUrl(u, options)
+}
+
+// rejects determines, without allocating, whether a path definitely does not match the template (see quickReject)
+//
+// paths are only quick rejected when there are no options and the template does not treat empty segments or normalize paths
+func (t *template) rejects(path string, options []interface{}) bool {
+	return len(options) == 0 && t.slashPolicy&emptySegmentPolicies == 0 && t.normalize == nil && t.quickReject(path)
+}
+
+func (t *template) matchesUrl(u *url.URL, options []interface{}) (PathVars, bool) {
+	policy := t.slashPolicyFor(options)
+	escaped, ok := t.matchPath(u, options)
+	if !ok || !t.trailingSlashMatches(escaped, policy) {
+		return nil, false
+	}
+	pts, ok := splitPathPolicy(escaped, policy)
+	if !ok {
+		return nil, false
+	}
+	if pts, ok = decodeSegments(pts, options); ok {
+		return t.matchSegments(u, pts, options)
+	}
+	return nil, false
+}
+
+// matchSegments matches the URL (host and query) and the (already split and decoded) path segments against the template
+func (t *template) matchSegments(u *url.URL, pts []string, options []interface{}) (PathVars, bool) {
+	return t.matchSegmentsDetail(u, pts, options, nil)
 }
 
-func (t *template) matches(path string, options ...interface{}) (PathVars, bool) {
-	pts, err := matchPathSplitter.Split(path)
-	if err != nil || len(pts) != len(t.pathParts) {
+// matchSegmentsDetail is the same as matchSegments - but records the reason for any mismatch in the detail (if not nil)
+func (t *template) matchSegmentsDetail(u *url.URL, pts []string, options []interface{}, detail *MatchResult) (PathVars, bool) {
+	if !t.allowsSegmentCount(len(pts)) {
+		detail.mismatch(MismatchSegmentCount, -1, "", "", nil)
 		return nil, false
 	}
 	result := newPathVars(t.varsType)
 	fixedOpts, varOpts := t.mergeParseOptions(options)
 	ok := true
-	for i, pt := range t.pathParts {
+	if t.host != nil && !t.host.match(u, result, fixedOpts, varOpts) {
+		detail.explainHostMismatch(t.host, u)
+		return nil, false
+	}
+	n := len(pts)
+	catchAll := t.hasCatchAll() && n >= len(t.pathParts)-1
+	if catchAll {
+		n = len(t.pathParts) - 1
+	}
+	for i, pt := range t.pathParts[:n] {
 		ok = pt.match(pts[i], i, result, fixedOpts, varOpts)
 		if !ok {
+			detail.explainMismatch(&t.pathParts[i], i, pts[i], result, fixedOpts, varOpts)
 			break
 		}
 	}
-	return result, ok
+	if ok && catchAll {
+		if ok = t.pathParts[n].matchCatchAll(pts[n:], n, result, varOpts); !ok {
+			detail.explainCatchAllMismatch(&t.pathParts[n], n, pts[n:])
+		}
+	}
+	if ok && len(t.queryParts) > 0 {
+		var q map[string][]string
+		if q, ok = parseRawQuery(u.RawQuery, encodingOption(options)); ok {
+			for _, qp := range t.queryParts {
+				if ok = qp.match(q[qp.name], result, varOpts); !ok {
+					detail.mismatch(MismatchQuery, -1, qp.name, strings.Join(q[qp.name], ","), nil)
+					break
+				}
+			}
+		} else {
+			detail.mismatch(MismatchQuery, -1, "", u.RawQuery, nil)
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return result, true
 }
 
 // Sub generates a new template with added sub-path
+//
+// the sub-path is parsed with the template options followed by any options specified - and the new template has
+// the options of both
 func (t *template) Sub(path string, options ...interface{}) (Template, error) {
-	add, err := NewTemplate(path, options...)
+	add, err := NewTemplate(path, append(t.Options(), options...)...)
 	if err != nil {
 		return nil, err
 	}
 	ra, _ := add.(*template)
-	if (ra.posVarsCount > 0 && t.nameVarsCount > 0) || (t.posVarsCount > 0 && ra.nameVarsCount > 0) {
+	if t.hasOptionalGroups() {
+		return nil, newTemplateParseError("cannot add sub-path to template with optional groups", 0, nil)
+	} else if t.hasCatchAll() {
+		return nil, newTemplateParseError("cannot add sub-path to template with catch-all var", 0, nil)
+	} else if ra.host != nil {
+		return nil, newTemplateParseError("sub-path cannot contain host", 0, nil)
+	} else if (ra.posVarsCount > 0 && t.nameVarsCount > 0) || (t.posVarsCount > 0 && ra.nameVarsCount > 0) {
 		return nil, newTemplateParseError("template cannot contain both positional and named path variables", 0, nil)
 	}
 	result := t.clone()
-	if strings.HasSuffix(result.originalTemplate, "/") {
-		result.originalTemplate = result.originalTemplate[:len(result.originalTemplate)-1] + ra.originalTemplate
-	} else {
-		result.originalTemplate = result.originalTemplate + ra.originalTemplate
+	basePath, _, _, _ := splitQuerySection(result.originalTemplate)
+	addPath, _, _, _ := splitQuerySection(ra.originalTemplate)
+	if strings.HasSuffix(basePath, "/") {
+		basePath = basePath[:len(basePath)-1]
 	}
 	for _, pt := range ra.pathParts {
 		result.pathParts = append(result.pathParts, pt)
 	}
+	for _, qp := range ra.queryParts {
+		for _, eqp := range result.queryParts {
+			if eqp.name == qp.name {
+				return nil, newTemplateParseError(fmt.Sprintf("duplicate query param '%s'", qp.name), 0, nil)
+			}
+		}
+		result.queryParts = append(result.queryParts, qp)
+	}
+	result.originalTemplate = basePath + addPath + querySection(result.queryParts, false)
 	result.posVarsCount += ra.posVarsCount
 	result.nameVarsCount += ra.nameVarsCount
+	result.fixedMatchOpts = ra.fixedMatchOpts
+	result.varMatchOpts = ra.varMatchOpts
+	result.pathSplitOpts = ra.pathSplitOpts
+	result.nonGreedySubParts = ra.nonGreedySubParts
+	result.slashPolicy = ra.slashPolicy
+	result.trailingSlash = ra.trailingSlash
+	result.normalize = ra.normalize
 	return result, nil
 }
 
@@ -213,88 +444,138 @@ func (t *template) ResolveTo(vars PathVars) (Template, error) {
 		namedPositions: map[string]int{},
 	}
 	result := &template{
-		pathParts:     make([]pathPart, 0, len(t.pathParts)),
-		posVarsCount:  0,
-		nameVarsCount: 0,
+		pathParts:         make([]pathPart, 0, len(t.pathParts)),
+		posVarsCount:      0,
+		nameVarsCount:     0,
+		varsType:          t.varsType,
+		fixedMatchOpts:    t.fixedMatchOpts,
+		varMatchOpts:      t.varMatchOpts,
+		pathSplitOpts:     t.pathSplitOpts,
+		nonGreedySubParts: t.nonGreedySubParts,
+		slashPolicy:       t.slashPolicy,
+		trailingSlash:     t.trailingSlash,
+		normalize:         t.normalize,
 	}
 	var orgBuilder strings.Builder
-	for _, pt := range t.pathParts {
-		if pt.fixed {
-			orgBuilder.WriteString(`/` + pt.fixedValue)
-			result.pathParts = append(result.pathParts, pt)
-		} else if len(pt.subParts) == 0 {
-			if str, err := tracker.getVar(&pt); err == nil {
-				orgBuilder.WriteString(`/` + str)
-				result.pathParts = append(result.pathParts, pathPart{
-					fixed:      true,
-					fixedValue: str,
-				})
-			} else {
-				result.pathParts = append(result.pathParts, pt)
-				if pt.name == "" {
-					result.posVarsCount++
-					orgBuilder.WriteString(`/?`)
+	if t.host != nil {
+		result.host = &hostTemplate{}
+		for i, pt := range t.host.parts() {
+			np, str := result.resolvePart(*pt, tracker)
+			switch {
+			case i == 0:
+				result.host.scheme = np
+			case t.host.port == pt:
+				result.host.port = &np
+				str = ":" + str
+			default:
+				result.host.labels = append(result.host.labels, np)
+				if i == 1 {
+					str = "://" + str
 				} else {
-					orgBuilder.WriteString(`/{` + pt.name)
-					result.nameVarsCount++
-					if pt.orgRegexp != "" {
-						orgBuilder.WriteString(`:` + pt.orgRegexp)
-					}
-					orgBuilder.WriteString(`}`)
+					str = "." + str
 				}
 			}
-		} else {
-			np := pathPart{
-				fixed:    false,
-				subParts: make([]pathPart, 0, len(pt.subParts)),
-			}
-			resolvedCount := 0
-			for _, sp := range pt.subParts {
-				if sp.fixed {
-					resolvedCount++
-					np.subParts = append(np.subParts, sp)
-				} else if str, err := tracker.getVar(&sp); err == nil {
-					resolvedCount++
-					np.subParts = append(np.subParts, pathPart{
-						fixed:      true,
-						fixedValue: str,
-					})
-				} else {
-					np.subParts = append(np.subParts, sp)
-					result.nameVarsCount++
-				}
-			}
-			orgBuilder.WriteString(`/`)
-			if resolvedCount == len(pt.subParts) {
-				fxnp := pathPart{
-					fixed:      true,
-					fixedValue: "",
-				}
-				for _, sp := range np.subParts {
-					orgBuilder.WriteString(sp.fixedValue)
-					fxnp.fixedValue += sp.fixedValue
-				}
-				np = fxnp
-			} else {
-				for _, sp := range np.subParts {
-					if sp.fixed {
-						orgBuilder.WriteString(sp.fixedValue)
-					} else {
-						orgBuilder.WriteString(`{` + sp.name)
-						if sp.orgRegexp != "" {
-							orgBuilder.WriteString(`:` + sp.orgRegexp)
-						}
-						orgBuilder.WriteString(`}`)
-					}
-				}
-			}
-			result.pathParts = append(result.pathParts, np)
+			orgBuilder.WriteString(str)
 		}
 	}
+	group := 0
+	for _, pt := range t.pathParts {
+		writeGroupBrackets(&orgBuilder, group, pt.group)
+		group = pt.group
+		np, str := result.resolvePart(pt, tracker)
+		orgBuilder.WriteString("/" + str)
+		result.pathParts = append(result.pathParts, np)
+	}
+	orgBuilder.WriteString(strings.Repeat("]", group))
+	if t.trailingSlash {
+		orgBuilder.WriteString("/")
+	}
+	result.queryParts = append(result.queryParts, t.queryParts...)
+	result.nameVarsCount += len(t.queryParts)
+	orgBuilder.WriteString(querySection(t.queryParts, false))
 	result.originalTemplate = orgBuilder.String()
+	if err := result.precompile(); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// resolvePart resolves any vars of the path part (that are present in the tracker vars) - returning the
+// resolved path part and its template text
+func (t *template) resolvePart(pt pathPart, tracker *positionsTracker) (pathPart, string) {
+	var orgBuilder strings.Builder
+	if pt.fixed {
+		return pt, pt.fixedValue
+	} else if pt.catchAll {
+		// catch-all vars are never resolved...
+		t.nameVarsCount++
+		return pt, pt.catchAllString()
+	} else if len(pt.subParts) == 0 {
+		if str, err := tracker.getVar(&pt); err == nil {
+			return pathPart{
+				fixed:      true,
+				fixedValue: str,
+				group:      pt.group,
+			}, str
+		} else if pt.name == "" {
+			t.posVarsCount++
+			return pt, "?"
+		}
+		orgBuilder.WriteString(`{` + pt.name)
+		t.nameVarsCount++
+		if pt.orgRegexp != "" {
+			orgBuilder.WriteString(`:` + pt.orgRegexp)
+		}
+		orgBuilder.WriteString(`}`)
+		return pt, orgBuilder.String()
+	}
+	np := pathPart{
+		fixed:     false,
+		subParts:  make([]pathPart, 0, len(pt.subParts)),
+		group:     pt.group,
+		nonGreedy: pt.nonGreedy,
+	}
+	resolvedCount := 0
+	for _, sp := range pt.subParts {
+		if sp.fixed {
+			resolvedCount++
+			np.subParts = append(np.subParts, sp)
+		} else if str, err := tracker.getVar(&sp); err == nil {
+			resolvedCount++
+			np.subParts = append(np.subParts, pathPart{
+				fixed:      true,
+				fixedValue: str,
+			})
+		} else {
+			np.subParts = append(np.subParts, sp)
+			t.nameVarsCount++
+		}
+	}
+	if resolvedCount == len(pt.subParts) {
+		fxnp := pathPart{
+			fixed:      true,
+			fixedValue: "",
+			group:      pt.group,
+		}
+		for _, sp := range np.subParts {
+			fxnp.fixedValue += sp.fixedValue
+		}
+		return fxnp, fxnp.fixedValue
+	}
+	for _, sp := range np.subParts {
+		if sp.fixed {
+			orgBuilder.WriteString(sp.fixedValue)
+		} else {
+			orgBuilder.WriteString(`{` + sp.name)
+			if sp.orgRegexp != "" {
+				orgBuilder.WriteString(`:` + sp.orgRegexp)
+			}
+			orgBuilder.WriteString(`}`)
+		}
+	}
+	return np, orgBuilder.String()
+}
+
 // VarsType returns the path vars type (Positions or Names)
 func (t *template) VarsType() PathVarsType {
 	if t.posVarsCount != 0 {
@@ -307,8 +588,24 @@ func (t *template) VarsType() PathVarsType {
 func (t *template) Vars() []PathVar {
 	result := make([]PathVar, 0, len(t.pathParts))
 	namePosns := map[string]int{}
+	for _, p := range t.host.parts() {
+		result = p.getVars(result, namePosns)
+	}
 	for _, p := range t.pathParts {
+		from := len(result)
 		result = p.getVars(result, namePosns)
+		for i := from; i < len(result); i++ {
+			result[i].Optional = result[i].Optional || p.group > 0
+		}
+	}
+	for _, qp := range t.queryParts {
+		result = append(result, PathVar{
+			Name:          qp.name,
+			NamedPosition: namePosns[qp.name],
+			Position:      len(result),
+			Optional:      !qp.required,
+		})
+		namePosns[qp.name]++
 	}
 	return result
 }
@@ -322,19 +619,53 @@ func (t *template) OriginalTemplate() string {
 func (t *template) Template(removePatterns bool) string {
 	if removePatterns {
 		var builder strings.Builder
-		if len(t.pathParts) == 0 {
+		if t.host != nil {
+			t.host.buildNoPattern(&builder)
+		} else if len(t.pathParts) == 0 {
 			builder.WriteString("/")
 		}
+		group := 0
 		for _, pt := range t.pathParts {
+			writeGroupBrackets(&builder, group, pt.group)
+			group = pt.group
 			builder.WriteString("/")
 			pt.buildNoPattern(&builder)
 		}
+		builder.WriteString(strings.Repeat("]", group))
+		builder.WriteString(querySection(t.queryParts, true))
 		return builder.String()
 	}
 	return t.originalTemplate
 }
 
-func separatePathOptions(options []interface{}) (host HostOption, params QueryParamsOption, headers HeadersOption, varMatches varMatchOptions) {
+// Options returns the options the template was created with (any FixedMatchOption, VarMatchOption, SubPartsOption etc.)
+//
+// templates generated using Sub or ResolveTo inherit the options of the template
+func (t *template) Options() []interface{} {
+	result := make([]interface{}, 0, len(t.fixedMatchOpts)+len(t.varMatchOpts)+len(t.pathSplitOpts)+3)
+	for _, o := range t.fixedMatchOpts {
+		result = append(result, o)
+	}
+	for _, o := range t.varMatchOpts {
+		result = append(result, o)
+	}
+	for _, o := range t.pathSplitOpts {
+		result = append(result, o)
+	}
+	if t.nonGreedySubParts {
+		result = append(result, NonGreedySubParts)
+	}
+	if t.slashPolicy != 0 {
+		result = append(result, t.slashPolicy)
+	}
+	if t.normalize != nil {
+		result = append(result, t.normalize)
+	}
+	return result
+}
+
+func separatePathOptions(options []interface{}) (host HostOption, params QueryParamsOption, headers HeadersOption, varMatches varMatchOptions, encoding EncodingOption) {
+	encoding = _PercentEncoded
 	for _, intf := range options {
 		if h, ok := intf.(HostOption); ok {
 			host = h
@@ -344,11 +675,35 @@ func separatePathOptions(options []interface{}) (host HostOption, params QueryPa
 			headers = hd
 		} else if v, ok := intf.(VarMatchOption); ok {
 			varMatches = append(varMatches, v)
+		} else if e, ok := intf.(EncodingOption); ok {
+			encoding = e
 		}
 	}
 	return
 }
 
+func encodingOption(options []interface{}) EncodingOption {
+	for _, intf := range options {
+		if e, ok := intf.(EncodingOption); ok {
+			return e
+		}
+	}
+	return _PercentEncoded
+}
+
+// decodeSegments decodes the split path segments (using any EncodingOption in the options)
+func decodeSegments(pts []string, options []interface{}) ([]string, bool) {
+	encoding := encodingOption(options)
+	for i, pt := range pts {
+		if s, ok := encoding.Decode(pt); ok {
+			pts[i] = s
+		} else {
+			return nil, false
+		}
+	}
+	return pts, true
+}
+
 func separateParseOptions(options []interface{}) (fixedMatchOptions, varMatchOptions, []splitter.Option) {
 	seenFixed := map[FixedMatchOption]bool{}
 	seenVar := map[VarMatchOption]bool{}
@@ -370,9 +725,13 @@ func separateParseOptions(options []interface{}) (fixedMatchOptions, varMatchOpt
 }
 
 func (t *template) mergeParseOptions(options []interface{}) (fixedMatchOptions, varMatchOptions) {
+	return mergeParseOptions(t.fixedMatchOpts, t.varMatchOpts, options)
+}
+
+func mergeParseOptions(fixedMatchOpts fixedMatchOptions, varMatchOpts varMatchOptions, options []interface{}) (fixedMatchOptions, varMatchOptions) {
 	if len(options) == 0 {
-		return t.fixedMatchOpts, t.varMatchOpts
-	} else if len(t.fixedMatchOpts) == 0 && len(t.varMatchOpts) == 0 {
+		return fixedMatchOpts, varMatchOpts
+	} else if len(fixedMatchOpts) == 0 && len(varMatchOpts) == 0 {
 		fs, vs, _ := separateParseOptions(options)
 		return fs, vs
 	}
@@ -380,11 +739,11 @@ func (t *template) mergeParseOptions(options []interface{}) (fixedMatchOptions,
 	seenFixed := map[FixedMatchOption]bool{}
 	vars := make(varMatchOptions, 0)
 	seenVars := map[VarMatchOption]bool{}
-	for _, f := range t.fixedMatchOpts {
+	for _, f := range fixedMatchOpts {
 		seenFixed[f] = true
 		fixed = append(fixed, f)
 	}
-	for _, v := range t.varMatchOpts {
+	for _, v := range varMatchOpts {
 		seenVars[v] = true
 		vars = append(vars, v)
 	}
@@ -409,12 +768,14 @@ var uriSplitter = splitter.MustCreateSplitter('/',
 
 type partCapture struct {
 	template *template
+	group    int
 }
 
 func (c *partCapture) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...splitter.SubPart) (string, bool, error) {
 	if pt, err := c.template.newUriPathPart(s, pos, subParts); err != nil {
 		return "", false, err
 	} else {
+		pt.group = c.group
 		c.template.pathParts = append(c.template.pathParts, pt)
 	}
 	return s, true, nil
@@ -422,13 +783,22 @@ func (c *partCapture) Apply(s string, pos int, totalLen int, captured int, skipp
 
 func (t *template) clone() *template {
 	result := &template{
-		originalTemplate: t.originalTemplate,
-		pathParts:        make([]pathPart, 0, len(t.pathParts)),
-		posVarsCount:     t.posVarsCount,
-		nameVarsCount:    t.nameVarsCount,
-		varsType:         t.varsType,
+		originalTemplate:  t.originalTemplate,
+		pathParts:         make([]pathPart, 0, len(t.pathParts)),
+		posVarsCount:      t.posVarsCount,
+		nameVarsCount:     t.nameVarsCount,
+		varsType:          t.varsType,
+		host:              t.host,
+		fixedMatchOpts:    t.fixedMatchOpts,
+		varMatchOpts:      t.varMatchOpts,
+		pathSplitOpts:     t.pathSplitOpts,
+		nonGreedySubParts: t.nonGreedySubParts,
+		slashPolicy:       t.slashPolicy,
+		trailingSlash:     t.trailingSlash,
+		normalize:         t.normalize,
 	}
 	result.pathParts = append(result.pathParts, t.pathParts...)
+	result.queryParts = append(result.queryParts, t.queryParts...)
 	return result
 }
 
@@ -436,26 +806,77 @@ func (t *template) parse() (Template, error) {
 	if strings.Trim(t.originalTemplate, " ") == "" {
 		return nil, newTemplateParseError("template empty", 0, nil)
 	}
-	splitOps := append(t.pathSplitOpts, &partCapture{template: t})
-	_, err := uriSplitter.Split(t.originalTemplate, splitOps...)
+	pathTemplate, section, qPos, err := splitQuerySection(t.originalTemplate)
+	if err != nil {
+		return nil, err
+	} else if qPos != -1 {
+		if t.queryParts, err = parseQueryParts(section, qPos); err != nil {
+			return nil, err
+		}
+		t.nameVarsCount += len(t.queryParts)
+	}
+	scheme, authority, aPos, pathTemplate := splitHostTemplate(pathTemplate)
+	if aPos != -1 {
+		if err = t.parseHost(scheme, authority, aPos); err != nil {
+			return nil, unwrapParseError(err)
+		}
+	}
+	t.trailingSlash = hasTrailingSlash(pathTemplate)
+	chunks, err := splitOptionalGroups(pathTemplate)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		if chunk.text == "" && chunk.group > 0 {
+			continue
+		}
+		splitOps := append(append(make([]splitter.Option, 0, len(t.pathSplitOpts)+1), t.pathSplitOpts...), &partCapture{template: t, group: chunk.group})
+		before := len(t.pathParts)
+		if _, err = uriSplitter.Split(chunk.text, splitOps...); err != nil {
+			break
+		} else if chunk.group > 0 && len(t.pathParts) == before {
+			return nil, newTemplateParseError("optional group cannot be empty", chunk.pos, nil)
+		}
+	}
 	if t.posVarsCount > 0 && t.nameVarsCount > 0 {
 		return nil, newTemplateParseError("template cannot contain both positional and named path variables", 0, nil)
 	} else if t.nameVarsCount > 0 {
 		t.varsType = Names
 	}
+	for i := 0; err == nil && i < len(t.pathParts)-1; i++ {
+		if t.pathParts[i].catchAll {
+			return nil, newTemplateParseError("catch-all var must be the last path segment", 0, nil)
+		}
+	}
+	if err == nil {
+		err = t.precompile()
+	}
+	return t, unwrapParseError(err)
+}
+
+// unwrapParseError unwraps any TemplateParseError wrapped by a splitter error
+func unwrapParseError(err error) error {
 	if err != nil {
 		if terr := errors.Unwrap(err); terr != nil {
 			if _, ok := terr.(TemplateParseError); ok {
-				err = terr
+				return terr
 			}
 		}
 	}
-	return t, err
+	return err
 }
 
 func (t *template) newUriPathPart(pt string, pos int, subParts []splitter.SubPart) (pathPart, error) {
 	if len(subParts) == 1 && subParts[0].Type() == splitter.Fixed {
-		if strings.HasPrefix(pt, "?") || strings.HasPrefix(pt, ":") {
+		if pt == catchAllName {
+			varPart := pathPart{
+				fixed:    false,
+				name:     catchAllName,
+				catchAll: true,
+			}
+			t.addVar(varPart)
+			return varPart, nil
+		} else if strings.HasPrefix(pt, "?") || strings.HasPrefix(pt, ":") {
 			varPart := pathPart{
 				fixed: false,
 				name:  pt[1:],
@@ -484,8 +905,9 @@ func (t *template) addVar(pt pathPart) {
 
 func (t *template) newVarPathPart(subParts []splitter.SubPart) (pathPart, error) {
 	result := pathPart{
-		fixed:    false,
-		subParts: make([]pathPart, 0),
+		fixed:     false,
+		subParts:  make([]pathPart, 0),
+		nonGreedy: t.nonGreedySubParts,
 	}
 	anyVarParts := false
 	for _, sp := range subParts {
@@ -514,6 +936,12 @@ func (t *template) newVarPathPart(subParts []splitter.SubPart) (pathPart, error)
 	}
 	if len(result.subParts) == 1 {
 		return result.subParts[0], nil
+	} else if anyVarParts {
+		for _, sp := range result.subParts {
+			if sp.catchAll {
+				return result, newTemplateParseError("catch-all var must be a whole path segment", subParts[0].StartPos(), nil)
+			}
+		}
 	} else if !anyVarParts {
 		var sb strings.Builder
 		for _, s := range result.subParts {
@@ -527,13 +955,10 @@ func (t *template) newVarPathPart(subParts []splitter.SubPart) (pathPart, error)
 	return result, nil
 }
 
-var matchPathSplitter = splitter.MustCreateSplitter('/').
-	AddDefaultOptions(splitter.IgnoreEmptyFirst, splitter.IgnoreEmptyLast, splitter.NotEmptyInners)
-
 func slashPrefix(s string) string {
 	if strings.Trim(s, " ") == "" {
 		return s
-	} else if strings.HasPrefix(s, "/") {
+	} else if strings.HasPrefix(s, "/") || hasHostTemplate(s) {
 		return s
 	}
 	return "/" + s
@@ -389,6 +389,141 @@ func TestTemplate_PathFrom_WithRegexCheck(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTemplate_PathFrom_Encoding(t *testing.T) {
+	tmp, err := NewTemplate(`/foo/{foo}/bar/{bar}-{baz}`)
+	require.NoError(t, err)
+
+	pth, err := tmp.PathFrom(Named("foo", "a/b c", "bar", "100%", "baz", "x?y"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/a%2Fb%20c/bar/100%25-x%3Fy`, pth)
+
+	pth, err = tmp.PathFrom(Named("foo", "a%2Fb", "bar", "1", "baz", "2"), PreEncoded)
+	require.NoError(t, err)
+	require.Equal(t, `/foo/a%2Fb/bar/1-2`, pth)
+
+	tmp, err = NewTemplate(`/foo/?`)
+	require.NoError(t, err)
+	pth, err = tmp.PathFrom(Positional("a/b"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/a%2Fb`, pth)
+}
+
+func TestTemplate_PathFrom_Encoding_Fixed(t *testing.T) {
+	testCases := []struct {
+		template string
+		options  []interface{}
+		expect   string
+	}{
+		{`/a b/{x}`, nil, `/a%20b/1`},
+		{`/a%20b/{x}`, nil, `/a%20b/1`},
+		{`/a?b/{x}`, nil, `/a%3Fb/1`},
+		{`/f/{x} {y}`, nil, `/f/1%202`},
+		{`/f/{x}%20{y}`, nil, `/f/1%202`},
+		{`/a b/{x}`, []interface{}{PreEncoded}, `/a b/1`},
+		{`/a%20b/{x}`, []interface{}{PreEncoded}, `/a%20b/1`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			pth, err := tmp.PathFrom(Named("x", "1", "y", "2"), tc.options...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pth)
+			if len(tc.options) == 0 {
+				_, ok := tmp.Matches(pth)
+				require.True(t, ok)
+			}
+		})
+	}
+}
+
+func TestTemplate_Matches_Decoding(t *testing.T) {
+	tmp, err := NewTemplate(`/foo/{foo}/bar/{bar}-{baz}`)
+	require.NoError(t, err)
+
+	vars, ok := tmp.Matches(`/foo/a%2Fb%20c/bar/100%25-x%3Fy`)
+	require.True(t, ok)
+	v, _ := vars.Get("foo")
+	require.Equal(t, `a/b c`, v)
+	v, _ = vars.Get("bar")
+	require.Equal(t, `100%`, v)
+	v, _ = vars.Get("baz")
+	require.Equal(t, `x?y`, v)
+
+	vars, ok = tmp.Matches(`/foo/a%2Fb%20c/bar/1-2`, PreEncoded)
+	require.True(t, ok)
+	v, _ = vars.Get("foo")
+	require.Equal(t, `a%2Fb%20c`, v)
+
+	u, err := url.Parse(`https://www.example.com/foo/a%2Fb/bar/1-2`)
+	require.NoError(t, err)
+	require.Equal(t, `a%2Fb`, u.RawPath[5:10])
+	vars, ok = tmp.MatchesUrl(*u)
+	require.True(t, ok)
+	v, _ = vars.Get("foo")
+	require.Equal(t, `a/b`, v)
+
+	req, err := tmp.RequestFrom("GET", Named("foo", "a/b c", "bar", "1", "baz", "2"), nil, NewHost(`https://www.example.com`))
+	require.NoError(t, err)
+	vars, ok = tmp.MatchesRequest(req)
+	require.True(t, ok)
+	v, _ = vars.Get("foo")
+	require.Equal(t, `a/b c`, v)
+
+	tmp, err = NewTemplate(`/foo bar/{foo}`)
+	require.NoError(t, err)
+	_, ok = tmp.Matches(`/foo%20bar/x`)
+	require.True(t, ok)
+	tmp, err = NewTemplate(`/foo%20bar/{foo}`)
+	require.NoError(t, err)
+	_, ok = tmp.Matches(`/foo%20bar/x`)
+	require.True(t, ok)
+}
+
+func TestTemplate_ResolveTo_Encoding(t *testing.T) {
+	tmp, err := NewTemplate(`/foo/{foo}/bar/{bar}`)
+	require.NoError(t, err)
+
+	tmp2, err := tmp.ResolveTo(Named("foo", "a/b"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/a%2Fb/bar/{bar}`, tmp2.OriginalTemplate())
+	pth, err := tmp2.PathFrom(Named("bar", "c d"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/a%2Fb/bar/c%20d`, pth)
+	vars, ok := tmp2.Matches(pth)
+	require.True(t, ok)
+	v, _ := vars.Get("bar")
+	require.Equal(t, `c d`, v)
+}
+
+func TestTemplate_ResolveTo_Encoding_SubParts(t *testing.T) {
+	tmp := MustCreateTemplate(`/foo/{a}-{b}`)
+	tmp2, err := tmp.ResolveTo(Named("a", "x y"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/x%20y-{b}`, tmp2.OriginalTemplate())
+	pth, err := tmp2.PathFrom(Named("b", "z"))
+	require.NoError(t, err)
+	require.Equal(t, `/foo/x%20y-z`, pth)
+	vars, ok := tmp2.Matches(pth)
+	require.True(t, ok)
+	v, _ := vars.Get("b")
+	require.Equal(t, `z`, v)
+	_, ok = tmp2.Matches(`/foo/x%20y-z`, PreEncoded)
+	require.True(t, ok)
+
+	tmp = MustCreateTemplate(`/foo/{a}%20{b}`)
+	vars, ok = tmp.Matches(`/foo/x%20y`)
+	require.True(t, ok)
+	v, _ = vars.Get("a")
+	require.Equal(t, `x`, v)
+	_, ok = tmp.Matches(`/foo/x%2Ay`)
+	require.False(t, ok)
+	tmp = MustCreateTemplate(`/foo/{a}.+{b}`)
+	_, ok = tmp.Matches(`/foo/x.+y`)
+	require.True(t, ok)
+	_, ok = tmp.Matches(`/foo/x..y`)
+	require.False(t, ok)
+}
+
 func TestTemplate_RequestFrom(t *testing.T) {
 	tmp, err := NewTemplate(`/foo/{foo-id}/bar/{bar-id}`)
 	require.NoError(t, err)
@@ -680,3 +815,58 @@ func (o *dummyVar) Match(value string, position int, name string, rx *regexp.Reg
 func (o *dummyVar) Applicable(value string, position int, name string, rx *regexp.Regexp, rxs string, pathPos int, vars PathVars) bool {
 	return true
 }
+
+func TestTemplate_Options(t *testing.T) {
+	tmp := MustCreateTemplate(`/FOO/{id}`)
+	require.Empty(t, tmp.Options())
+
+	tmp = MustCreateTemplate(`/FOO/{id}`, CaseInsensitiveFixed, NonGreedySubParts, CaseInsensitiveFixed)
+	require.Equal(t, []interface{}{CaseInsensitiveFixed, NonGreedySubParts}, tmp.Options())
+	_, ok := tmp.Matches(`/foo/1`)
+	require.True(t, ok)
+
+	t.Run("Sub", func(t *testing.T) {
+		sub, err := tmp.Sub(`/BAR/{a}-{b}`)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed, NonGreedySubParts}, sub.Options())
+		vars, ok := sub.Matches(`/foo/1/bar/x-y-z`)
+		require.True(t, ok)
+		a, _ := vars.Get("a")
+		require.Equal(t, "x", a)
+
+		sub, err = tmp.Sub(`/BAR/{a}-{b}`, PathRegexCheck, GreedySubParts)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed, PathRegexCheck}, sub.Options())
+		vars, ok = sub.Matches(`/foo/1/bar/x-y-z`)
+		require.True(t, ok)
+		a, _ = vars.Get("a")
+		require.Equal(t, "x-y", a)
+
+		subSub, err := sub.Sub(`/BAZ`)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed, PathRegexCheck}, subSub.Options())
+		_, ok = subSub.Matches(`/foo/1/bar/x-y-z/baz`)
+		require.True(t, ok)
+	})
+	t.Run("ResolveTo", func(t *testing.T) {
+		rt, err := tmp.ResolveTo(Named("id", "1"))
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed, NonGreedySubParts}, rt.Options())
+		_, ok := rt.Matches(`/foo/1`)
+		require.True(t, ok)
+		_, ok = rt.Matches(`/Foo/1`)
+		require.True(t, ok)
+	})
+	t.Run("Rfc6570", func(t *testing.T) {
+		tmp := MustCreateRfc6570Template(`/FOO{/id}`, CaseInsensitiveFixed)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed}, tmp.Options())
+		sub, err := tmp.Sub(`/BAR`, PathRegexCheck)
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed, PathRegexCheck}, sub.Options())
+		_, ok := sub.Matches(`/foo/1/bar`)
+		require.True(t, ok)
+		rt, err := tmp.ResolveTo(Named("id", "1"))
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{CaseInsensitiveFixed}, rt.Options())
+	})
+}
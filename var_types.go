@@ -0,0 +1,153 @@
+package urit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VarType is a named path var type constraint - which can be used in templates in place of a path var regexp
+// (e.g. `{id:uuid}`, `{n:int}` or `{e:enum(a|b|c)}`)
+//
+// When matching, path var values must match the type regexp and be convertible by the type (the converted
+// value is available as PathVar.TypedValue or using PathVars.GetTyped)
+//
+// Built-in types are: int, uint, float, bool, uuid, date (yyyy-mm-dd), slug and enum (e.g. `enum(a|b|c)`)
+type VarType interface {
+	// Regexp returns the regexp for the type (args are any args specified in the template - e.g. `a|b|c` for `enum(a|b|c)`)
+	Regexp(args string) (string, error)
+	// Convert converts the path var value to the typed value (returning an error if the value is invalid for the type)
+	Convert(value string, args string) (interface{}, error)
+}
+
+// RegisterVarType registers a named path var type constraint (replacing any existing type with the same name)
+//
+// Registered types only affect templates created after registration
+func RegisterVarType(name string, vt VarType) {
+	varTypesMutex.Lock()
+	defer varTypesMutex.Unlock()
+	varTypes[name] = vt
+}
+
+// RegisterVarTypeFunc registers a named path var type constraint (that takes no args) from a regexp and
+// a convert func (if the convert func is nil, the path var value is not converted)
+func RegisterVarTypeFunc(name string, rx string, convert func(value string) (interface{}, error)) {
+	RegisterVarType(name, &varTypeFunc{rx: rx, convert: convert})
+}
+
+var (
+	varTypesMutex sync.RWMutex
+	varTypes      = map[string]VarType{
+		"int": &varTypeFunc{rx: `-?[0-9]+`, convert: func(value string) (interface{}, error) {
+			return strconv.Atoi(value)
+		}},
+		"uint": &varTypeFunc{rx: `[0-9]+`, convert: func(value string) (interface{}, error) {
+			v, err := strconv.ParseUint(value, 10, 0)
+			return uint(v), err
+		}},
+		"float": &varTypeFunc{rx: `-?[0-9]+(\.[0-9]+)?`, convert: func(value string) (interface{}, error) {
+			return strconv.ParseFloat(value, 64)
+		}},
+		"bool": &varTypeFunc{rx: `(true|false)`, convert: func(value string) (interface{}, error) {
+			return strconv.ParseBool(value)
+		}},
+		"uuid": &varTypeFunc{rx: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`},
+		"date": &varTypeFunc{rx: `[0-9]{4}-[0-9]{2}-[0-9]{2}`, convert: func(value string) (interface{}, error) {
+			return time.Parse("2006-01-02", value)
+		}},
+		"slug": &varTypeFunc{rx: `[a-z0-9]+(-[a-z0-9]+)*`},
+		"enum": &enumVarType{},
+	}
+)
+
+func lookupVarType(name string) (VarType, bool) {
+	varTypesMutex.RLock()
+	defer varTypesMutex.RUnlock()
+	vt, ok := varTypes[name]
+	return vt, ok
+}
+
+// parseVarType determines whether a path var regexp is a var type (e.g. `int` or `enum(a|b)`) - returning the
+// var type, its args and regexp (if not a var type, the regexp is returned as is)
+func parseVarType(s string) (VarType, string, string, error) {
+	name, args := s, ""
+	if i := strings.IndexByte(s, '('); i > 0 && strings.HasSuffix(s, ")") {
+		name, args = s[:i], s[i+1:len(s)-1]
+	}
+	if vt, ok := lookupVarType(name); ok {
+		rx, err := vt.Regexp(args)
+		return vt, args, rx, err
+	}
+	return nil, "", s, nil
+}
+
+type varTypeFunc struct {
+	rx      string
+	convert func(value string) (interface{}, error)
+}
+
+func (vt *varTypeFunc) Regexp(args string) (string, error) {
+	if args != "" {
+		return "", errors.New("var type does not take args")
+	}
+	return vt.rx, nil
+}
+
+func (vt *varTypeFunc) Convert(value string, args string) (interface{}, error) {
+	if vt.convert == nil {
+		return value, nil
+	}
+	return vt.convert(value)
+}
+
+type enumVarType struct{}
+
+func (vt *enumVarType) Regexp(args string) (string, error) {
+	if args == "" {
+		return "", errors.New("enum var type must have values")
+	}
+	values := strings.Split(args, "|")
+	for i, v := range values {
+		values[i] = regexp.QuoteMeta(v)
+	}
+	return "(" + strings.Join(values, "|") + ")", nil
+}
+
+func (vt *enumVarType) Convert(value string, args string) (interface{}, error) {
+	for _, v := range strings.Split(args, "|") {
+		if v == value {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("value must be one of %s", args)
+}
+
+// typedVar is the var type (if any) of a path part or query part
+type typedVar struct {
+	varType     VarType
+	varTypeArgs string
+}
+
+// convertTyped converts the value using the var type (if any)
+func (tv *typedVar) convertTyped(value string) (interface{}, bool) {
+	if tv.varType == nil {
+		return nil, true
+	}
+	result, err := tv.varType.Convert(value, tv.varTypeArgs)
+	return result, err == nil
+}
+
+// setLastTypedValue sets the typed value of the last var added to the vars
+func setLastTypedValue(vars PathVars, tv interface{}) {
+	if pvs, ok := vars.(*pathVars); ok && tv != nil && len(pvs.all) > 0 {
+		last := &pvs.all[len(pvs.all)-1]
+		last.TypedValue = tv
+		if pvs.varsType == Names {
+			pvs.named[last.Name][last.NamedPosition].TypedValue = tv
+		}
+	}
+}
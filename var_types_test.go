@@ -0,0 +1,145 @@
+package urit
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplate_VarTypes_Matches(t *testing.T) {
+	testCases := []struct {
+		template    string
+		path        string
+		expectOk    bool
+		expectTyped interface{}
+	}{
+		{`/orders/{id:int}`, `/orders/-12`, true, -12},
+		{`/orders/{id:int}`, `/orders/x`, false, nil},
+		{`/orders/{id:int}`, `/orders/99999999999999999999`, false, nil},
+		{`/orders/{id:uint}`, `/orders/12`, true, uint(12)},
+		{`/orders/{id:uint}`, `/orders/-12`, false, nil},
+		{`/prices/{p:float}`, `/prices/1.5`, true, 1.5},
+		{`/flags/{f:bool}`, `/flags/true`, true, true},
+		{`/flags/{f:bool}`, `/flags/yes`, false, nil},
+		{`/users/{id:uuid}`, `/users/3b241101-e2bb-4255-8caf-4136c566a962`, true, "3b241101-e2bb-4255-8caf-4136c566a962"},
+		{`/users/{id:uuid}`, `/users/3b241101`, false, nil},
+		{`/credits/{d:date}`, `/credits/2022-11-30`, true, time.Date(2022, 11, 30, 0, 0, 0, 0, time.UTC)},
+		{`/credits/{d:date}`, `/credits/2022-13-45`, false, nil},
+		{`/posts/{s:slug}`, `/posts/hello-world`, true, "hello-world"},
+		{`/posts/{s:slug}`, `/posts/Hello-World`, false, nil},
+		{`/orders/{s:enum(open|closed)}`, `/orders/open`, true, "open"},
+		{`/orders/{s:enum(open|closed)}`, `/orders/other`, false, nil},
+		{`/orders/{s:enum(a.b|c)}`, `/orders/axb`, false, nil},
+		{`/orders/{id:int}-{s:enum(a|b)}`, `/orders/1-a`, true, 1},
+		{`/orders/{id:int}-{s:enum(a|b)}`, `/orders/x-a`, false, nil},
+		{`/search{?page:int}`, `/search?page=2`, true, 2},
+		{`/search{?page:int}`, `/search?page=x`, false, nil},
+		{`/orders/{id:uuid4}`, `/orders/uuid4`, true, "uuid4"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmp := MustCreateTemplate(tc.template)
+			vars, ok := tmp.Matches(tc.path)
+			require.Equal(t, tc.expectOk, ok)
+			if ok {
+				tv, ok := vars.GetTyped(0)
+				require.True(t, ok)
+				require.Equal(t, tc.expectTyped, tv)
+			}
+		})
+	}
+}
+
+func TestTemplate_VarTypes_ParseErrors(t *testing.T) {
+	testCases := []struct {
+		template  string
+		expectErr string
+	}{
+		{`/orders/{id:int(x)}`, `path var type problem`},
+		{`/orders/{s:enum()}`, `path var type problem`},
+		{`/search{?s:enum()}`, `query param type problem`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			_, err := NewTemplate(tc.template)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestTemplate_VarTypes_PathFrom(t *testing.T) {
+	tmp := MustCreateTemplate(`/credits/{d:date}/{s:enum(open|closed)}`)
+	pth, err := tmp.PathFrom(Named("d", "2022-11-30", "s", "open"), PathRegexCheck)
+	require.NoError(t, err)
+	require.Equal(t, `/credits/2022-11-30/open`, pth)
+	_, err = tmp.PathFrom(Named("d", "2022-13-45", "s", "open"), PathRegexCheck)
+	require.Error(t, err)
+	_, err = tmp.PathFrom(Named("d", "2022-11-30", "s", "other"), PathRegexCheck)
+	require.Error(t, err)
+	pth, err = tmp.PathFrom(Named("d", "x", "s", "y"))
+	require.NoError(t, err)
+	require.Equal(t, `/credits/x/y`, pth)
+	require.Equal(t, `/credits/{d:date}/{s:enum(open|closed)}`, tmp.OriginalTemplate())
+}
+
+func TestTemplate_VarTypes_GetTyped(t *testing.T) {
+	vars, ok := MustCreateTemplate(`/orders/{id:int}/{name}`).Matches(`/orders/1/x`)
+	require.True(t, ok)
+	tv, ok := vars.GetTyped("id")
+	require.True(t, ok)
+	require.Equal(t, 1, tv)
+	tv, ok = vars.GetTyped("name")
+	require.True(t, ok)
+	require.Equal(t, "x", tv)
+	require.Equal(t, []PathVar{
+		{Name: "id", Position: 0, Value: "1", TypedValue: 1},
+		{Name: "name", Position: 1, Value: "x"},
+	}, vars.GetAll())
+	_, ok = vars.GetTyped("other")
+	require.False(t, ok)
+}
+
+type upperVarType struct{}
+
+func (vt *upperVarType) Regexp(args string) (string, error) {
+	if args != "" {
+		return "[A-Z]{" + args + "}", nil
+	}
+	return "[A-Z]+", nil
+}
+
+func (vt *upperVarType) Convert(value string, args string) (interface{}, error) {
+	return strings.ToLower(value), nil
+}
+
+func TestRegisterVarType(t *testing.T) {
+	RegisterVarType("test-upper", &upperVarType{})
+	tmp := MustCreateTemplate(`/codes/{code:test-upper(3)}`)
+	vars, ok := tmp.Matches(`/codes/ABC`)
+	require.True(t, ok)
+	tv, _ := vars.GetTyped("code")
+	require.Equal(t, "abc", tv)
+	_, ok = tmp.Matches(`/codes/ABCD`)
+	require.False(t, ok)
+
+	RegisterVarTypeFunc("test-even", `[0-9]+`, func(value string) (interface{}, error) {
+		if (value[len(value)-1]-'0')%2 != 0 {
+			return nil, errors.New("not even")
+		}
+		return value, nil
+	})
+	tmp = MustCreateTemplate(`/numbers/{n:test-even}`)
+	_, ok = tmp.Matches(`/numbers/12`)
+	require.True(t, ok)
+	_, ok = tmp.Matches(`/numbers/13`)
+	require.False(t, ok)
+
+	RegisterVarTypeFunc("test-any", `.+`, nil)
+	vars, ok = MustCreateTemplate(`/any/{a:test-any}`).Matches(`/any/x`)
+	require.True(t, ok)
+	tv, _ = vars.GetTyped("a")
+	require.Equal(t, "x", tv)
+}